@@ -0,0 +1,27 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package cleanup
+
+import (
+	"gocleasy/files"
+	"os"
+)
+
+// checkWritable checks that files can be added to or removed from the folder.
+// Only the read-only attribute is known on this OS
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return &os.PathError{Op: "access", Path: dir, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+// sameInode checks that info describes the file that was scanned. Inodes are not
+// read on this OS so it is always assumed
+func sameInode(file *files.File, info os.FileInfo) bool {
+	return true
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package cleanup
+
+import (
+	"gocleasy/files"
+	"os"
+	"syscall"
+)
+
+// Permissions checked by access(2)
+const (
+	accessWrite   = 0x2
+	accessExecute = 0x1
+)
+
+// checkWritable checks that files can be added to or removed from the folder
+func checkWritable(dir string) error {
+	if err := syscall.Access(dir, accessWrite|accessExecute); err != nil {
+		return &os.PathError{Op: "access", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// sameInode checks that info describes the file that was scanned and not another
+// one created with the same name since then
+func sameInode(file *files.File, info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || file.Inode == 0 {
+		return true
+	}
+	return uint64(st.Ino) == file.Inode && uint64(st.Dev) == file.Device
+}
@@ -0,0 +1,160 @@
+package cleanup
+
+import (
+	"gocleasy/files"
+	"gocleasy/protect"
+	"gocleasy/quarantine"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelection(t *testing.T) {
+	folder := files.NewTestFolder("b",
+		files.NewTestFile("c", 100),
+		files.NewTestFolder("d",
+			files.NewTestFile("e", 50),
+			files.NewTestFile("f", 30),
+		),
+	)
+	c, d, e := files.FindTestFile(folder, "c"), files.FindTestFile(folder, "d"), files.FindTestFile(folder, "e")
+
+	var selection Selection
+	selection.Select(c)
+	selection.Select(c)
+	assert.Equal(t, []*files.File{c}, selection.Files, "a file should only be selected once")
+	assert.True(t, selection.Toggle(e))
+	assert.True(t, selection.Toggle(d))
+	assert.Equal(t, Totals{NumFiles: 4, Size: 230, Freed: 180}, selection.Totals(files.ApparentSize))
+
+	assert.False(t, selection.Toggle(c))
+	assert.False(t, selection.IsSelected(c))
+	selection.UnselectInside(d)
+	assert.Equal(t, []*files.File{d}, selection.Files)
+	selection.Clear()
+	assert.Equal(t, 0, selection.Len())
+}
+
+func TestDelete(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d", "g"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "g", "h"), make([]byte, 30), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	g, h := files.FindTestFile(root, "g"), files.FindTestFile(root, "h")
+	missing := files.FindTestFile(root, "c")
+	assert.NoError(t, os.Remove(filepath.Join(dir, "c")))
+	hfile, err := files.Stat(filepath.Join(dir, "d", "g", "h"))
+	assert.NoError(t, err)
+
+	selection := Selection{Files: []*files.File{h, g, missing}}
+	report := Delete(root, &selection, PermanentMode, &protect.Guard{})
+	assert.Len(t, report.Results, 2, "files inside a deleted folder should go with it")
+	assert.Same(t, g, report.Results[0].File)
+	assert.Equal(t, Result{File: missing, Path: filepath.Join(dir, "c"), Gone: true}, *report.Results[1], "a file already gone frees nothing")
+	assert.Equal(t, 1, report.Gone)
+	assert.Equal(t, int64(1), report.NumFiles)
+	assert.Equal(t, hfile.Usage, report.Bytes, "the space freed should be measured in the disk")
+	assert.Equal(t, report.Bytes, report.Freed())
+	assert.Zero(t, report.Failed)
+	assert.Empty(t, selection.Files)
+	assert.NoDirExists(t, filepath.Join(dir, "d", "g"))
+	assert.FileExists(t, filepath.Join(dir, "d", "e"))
+	assert.Nil(t, files.FindTestFile(root, "g"))
+	assert.Equal(t, int64(50), root.Size)
+}
+
+func TestDeleteChanged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes are not read on windows")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	c, e := files.FindTestFile(root, "c"), files.FindTestFile(root, "e")
+
+	// Another file takes the place of c, and a folder the place of e
+	assert.NoError(t, os.Rename(filepath.Join(dir, "c"), filepath.Join(dir, "c.old")))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), []byte("new"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "e")))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "e"), 0755))
+
+	selection := Selection{Files: []*files.File{c, e}}
+	report := Delete(root, &selection, PermanentMode, &protect.Guard{})
+	assert.Equal(t, 2, report.Failed)
+	assert.ErrorIs(t, report.Results[0].Err, ErrChanged)
+	assert.ErrorIs(t, report.Results[1].Err, ErrChanged)
+	assert.FileExists(t, filepath.Join(dir, "c"), "a file that is not the one scanned should not be deleted")
+	assert.DirExists(t, filepath.Join(dir, "e"))
+	assert.Len(t, selection.Files, 2)
+}
+
+func TestDeleteFailure(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Getuid() == 0 {
+		t.Skip("permissions are only checked on linux without root")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d", "f"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "f", "g"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "d", "f"), 0555))
+	defer os.Chmod(filepath.Join(dir, "d", "f"), 0755)
+
+	d := files.FindTestFile(root, "d")
+	selection := Selection{Files: []*files.File{d}}
+	report := Delete(root, &selection, PermanentMode, &protect.Guard{})
+	assert.Equal(t, 1, report.Failed)
+	assert.ErrorIs(t, report.Failures()[0].Err, os.ErrPermission)
+	assert.Equal(t, int64(1), report.NumFiles, "the files deleted before failing should be counted")
+	assert.Equal(t, []*files.File{d}, selection.Files, "what could not be deleted should stay selected")
+	assert.Same(t, d, files.FindTestFile(root, "d"))
+}
+
+func TestDeleteProtected(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e.kdbx"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+
+	c, d := files.FindTestFile(root, "c"), files.FindTestFile(root, "d")
+	selection := Selection{Files: []*files.File{d, c}}
+	report := Delete(root, &selection, PermanentMode, &protect.Guard{Globs: []string{"*.kdbx"}})
+	assert.Equal(t, 1, report.Failed)
+	assert.ErrorIs(t, report.Failures()[0].Err, protect.ErrProtected)
+	assert.Equal(t, []*files.File{d}, selection.Files, "protected files should stay selected")
+	assert.FileExists(t, filepath.Join(dir, "d", "e.kdbx"))
+	assert.NoFileExists(t, filepath.Join(dir, "c"))
+}
+
+func TestDeleteToQuarantine(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the quarantine folder is only checked on linux")
+	}
+	home, dir := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+
+	selection := Selection{Files: []*files.File{files.FindTestFile(root, "c"), files.FindTestFile(root, "e")}}
+	report := Delete(root, &selection, QuarantineMode, &protect.Guard{})
+	assert.Zero(t, report.Failed)
+	assert.Zero(t, report.Freed(), "the space is freed when the quarantine is purged")
+	assert.NoFileExists(t, filepath.Join(dir, "c"))
+
+	batches, err := quarantine.List(filepath.Join(home, ".gocleasy", "quarantine"))
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1, "the files deleted together should be in the same batch")
+	assert.Len(t, batches[0].Items, 2)
+	assert.Equal(t, report.Bytes, batches[0].Size())
+	assert.NoError(t, batches[0].Restore())
+	assert.FileExists(t, filepath.Join(dir, "c"))
+}
@@ -0,0 +1,193 @@
+package cleanup
+
+import (
+	"gocleasy/files"
+	"gocleasy/protect"
+	"gocleasy/quarantine"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+// Mode is how the selected files are deleted
+type Mode int
+
+const (
+	MoveToTrashMode Mode = iota // Move the files to the trash, so they can be restored
+	PermanentMode               // Delete the files for good
+	QuarantineMode              // Move the files to the quarantine of gocleasy, so they can be restored until purged
+)
+
+// Modes lists the deletion modes of this OS, the default one first. Without a
+// trash the files are quarantined by default, so they can still be restored
+var Modes = availableModes()
+
+// DefaultMode is how files are deleted unless another mode is chosen
+var DefaultMode = Modes[0]
+
+func availableModes() []Mode {
+	if !trashSupported {
+		return []Mode{QuarantineMode, PermanentMode}
+	}
+	return []Mode{MoveToTrashMode, QuarantineMode, PermanentMode}
+}
+
+func (m Mode) String() string {
+	switch m {
+	case MoveToTrashMode:
+		return "Move to Trash"
+	case PermanentMode:
+		return "Delete permanently"
+	case QuarantineMode:
+		return "Quarantine"
+	}
+	return "Unknown"
+}
+
+// remover removes files from their place in the disk the way a mode says
+type remover struct {
+	mode  Mode
+	batch *quarantine.Batch // Where the files are quarantined, created with the first one
+}
+
+// remove removes the file at path, which contains numfiles files using size bytes
+func (r *remover) remove(path string, numfiles int64, size int64) error {
+	switch r.mode {
+	case PermanentMode:
+		return os.RemoveAll(path)
+	case QuarantineMode:
+		if r.batch == nil {
+			dir, err := quarantine.DefaultDir()
+			if err != nil {
+				return err
+			}
+			r.batch, err = quarantine.NewBatch(dir, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		return r.batch.Add(path, numfiles, size)
+	}
+	return MoveToTrash(path)
+}
+
+// close finishes the deletion, removing the quarantine batch if nothing was added
+func (r *remover) close() {
+	if r.batch != nil {
+		if err := r.batch.Close(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Delete deletes the selected files from disk and from the tree in root, and reports
+// what happened to each of them. Files the guard protects are refused without
+// deleting them. What could not be deleted stays selected
+func Delete(root *files.File, selection *Selection, mode Mode, guard *protect.Guard) *Report {
+
+	report := &Report{Mode: mode}
+	var deleted []*files.File
+	r := &remover{mode: mode}
+	defer r.close()
+
+	// Loop over selected files and delete them
+	for _, file := range selection.Files {
+		// Files inside a selected folder go with it
+		if isInsideAny(file, selection.Files) {
+			continue
+		}
+		result := &Result{File: file, Path: file.Path()}
+		report.Results = append(report.Results, result)
+		if result.Err = guard.Check(file); result.Err != nil {
+			report.Failed++
+			continue
+		}
+
+		// Another file may be at the path since the scan, like when deleting from an old snapshot
+		info, err := os.Lstat(result.Path)
+		if os.IsNotExist(err) {
+			// Nothing to delete, it is not in the disk anymore
+			result.Gone = true
+			report.Gone++
+			deleted = append(deleted, file)
+			continue
+		}
+		if err == nil && (info.IsDir() != file.IsDir || !sameInode(file, info)) {
+			result.Err = ErrChanged
+			report.Failed++
+			continue
+		}
+
+		// Measure what is really in the disk, it may have changed since the scan
+		numfiles, bytes, _ := measure(result.Path)
+		result.Err = r.remove(result.Path, numfiles, bytes)
+		if result.Err != nil {
+			// Part of a folder may have been deleted before failing
+			left, leftbytes, _ := measure(result.Path)
+			numfiles, bytes = numfiles-left, bytes-leftbytes
+			report.Failed++
+		} else {
+			deleted = append(deleted, file)
+		}
+		result.NumFiles, result.Bytes = numfiles, bytes
+		report.NumFiles += numfiles
+		report.Bytes += bytes
+	}
+
+	// Keep selected only what could not be deleted
+	selection.keep(func(file *files.File) bool {
+		return !isInsideAny(file, deleted) && !contains(deleted, file)
+	})
+
+	// The folders containing the deleted files show their new sizes
+	for _, file := range deleted {
+		if file == root {
+			// Nothing is left of the tree
+			continue
+		}
+		if err := root.Remove(file); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return report
+}
+
+// measure returns how many files there are at path and the space they use in the
+// disk, counting hard linked files only if all their links are inside. Filesystems
+// mounted inside are not counted, their space is not freed by deleting the folder
+func measure(path string) (int64, int64, error) {
+	file, err := files.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if file.IsDir {
+		file = files.WalkFolder(path, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{OneFileSystem: true}).Root
+	}
+	numfiles := int64(1)
+	if file.IsDir {
+		numfiles = file.NumChildren
+	}
+	return numfiles, files.ReclaimableSize([]*files.File{file}, files.DiskUsage), nil
+}
+
+// Checks if file is inside any of the folders
+func isInsideAny(file *files.File, folders []*files.File) bool {
+	for _, folder := range folders {
+		if file.IsInside(folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if file is one of the files in the slice
+func contains(slice []*files.File, file *files.File) bool {
+	for _, f := range slice {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package cleanup
+
+import (
+	"errors"
+	"gocleasy/files"
+	"gocleasy/protect"
+	"gocleasy/quarantine"
+	"os"
+	"path/filepath"
+)
+
+// Problems expected when deleting a selected file
+var (
+	ErrVanished = errors.New("does not exist anymore")
+	ErrChanged  = errors.New("changed since the scan")
+)
+
+// PlanEntry tells what deleting a selected file would do
+type PlanEntry struct {
+	File     *files.File
+	Path     string
+	NumFiles int64       // Files deleted, counting the ones inside the folder
+	Size     int64       // Size of the file or folder
+	Inside   *files.File // Selected folder containing the file, which deletes it too
+	Problem  error       // Why the file is expected not to be deleted, nil if it would be
+}
+
+// Plan is what deleting the selection would do, worked out without changing anything
+// in the disk
+type Plan struct {
+	Mode     Mode
+	Entries  []*PlanEntry // One for every selected file, in the order they were selected
+	NumFiles int64        // Files that would be deleted
+	Size     int64        // Size of the files that would be deleted
+	Freed    int64        // Space that would be freed, without hard links kept outside the selection
+	Problems int          // Selected files that would not be deleted
+}
+
+// NewPlan checks every selected file as it is now in the disk: if the guard protects
+// it, if it still exists, if it is the same that was scanned and if there are
+// permissions to delete it
+func NewPlan(selection *Selection, mode Mode, metric files.SizeMetric, guard *protect.Guard) *Plan {
+	plan := &Plan{Mode: mode}
+	var deleted []*files.File
+	for _, file := range selection.Files {
+		entry := &PlanEntry{File: file, Path: file.Path(), NumFiles: 1, Size: file.SizeBy(metric)}
+		if file.IsDir {
+			entry.NumFiles = file.NumChildren
+		}
+		plan.Entries = append(plan.Entries, entry)
+
+		// Files inside a selected folder go with it
+		for _, folder := range selection.Files {
+			if file.IsInside(folder) {
+				entry.Inside = folder
+				break
+			}
+		}
+		if entry.Inside != nil {
+			continue
+		}
+
+		entry.Problem = guard.Check(file)
+		if entry.Problem == nil {
+			entry.Problem = checkRemovable(file, entry.Path, mode)
+		}
+		if entry.Problem != nil {
+			plan.Problems++
+			continue
+		}
+		plan.NumFiles += entry.NumFiles
+		plan.Size += entry.Size
+		deleted = append(deleted, file)
+	}
+
+	// Hard linked files only free space when all their links are deleted
+	plan.Freed = files.ReclaimableSize(deleted, metric)
+	return plan
+}
+
+// checkRemovable returns why the file at path cannot be deleted the way mode says
+func checkRemovable(file *files.File, path string, mode Mode) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return ErrVanished
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() != file.IsDir || !sameInode(file, info) {
+		return ErrChanged
+	}
+	if mode == MoveToTrashMode && !trashSupported {
+		return ErrTrashUnsupported
+	}
+	// Files are moved to the quarantine, which cannot be done across filesystems
+	if mode == QuarantineMode {
+		dir, err := quarantine.DefaultDir()
+		if err != nil {
+			return err
+		}
+		if err := quarantine.CheckFilesystem(dir, path); err != nil {
+			return err
+		}
+	}
+
+	// Removing the file changes the folder containing it
+	if err := checkWritable(filepath.Dir(path)); err != nil {
+		return err
+	}
+	// Deleting a folder for good removes its content first, moving it does not
+	if mode == PermanentMode && file.IsDir {
+		return checkContentWritable(file, path)
+	}
+	return nil
+}
+
+// checkContentWritable checks that the content of every folder scanned inside the folder can be removed
+func checkContentWritable(folder *files.File, path string) error {
+	if len(folder.Files) == 0 {
+		return nil
+	}
+	if err := checkWritable(path); err != nil {
+		return err
+	}
+	for _, file := range folder.Files {
+		if file.IsDir {
+			if err := checkContentWritable(file, filepath.Join(path, file.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cleanup
+
+import (
+	"gocleasy/files"
+	"gocleasy/protect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "g"), make([]byte, 10), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	c, d, e, g := files.FindTestFile(root, "c"), files.FindTestFile(root, "d"), files.FindTestFile(root, "e"), files.FindTestFile(root, "g")
+	assert.NoError(t, os.Remove(filepath.Join(dir, "c")))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "g")))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "g"), 0755))
+
+	selection := Selection{Files: []*files.File{c, d, e, g}}
+	plan := NewPlan(&selection, PermanentMode, files.ApparentSize, &protect.Guard{})
+	assert.Len(t, plan.Entries, 4)
+	assert.ErrorIs(t, plan.Entries[0].Problem, ErrVanished)
+	assert.NoError(t, plan.Entries[1].Problem)
+	assert.Equal(t, filepath.Join(dir, "d"), plan.Entries[1].Path)
+	assert.Same(t, d, plan.Entries[2].Inside, "files inside a selected folder go with it")
+	assert.ErrorIs(t, plan.Entries[3].Problem, ErrChanged)
+	assert.Equal(t, 2, plan.Problems)
+	assert.Equal(t, int64(1), plan.NumFiles)
+	assert.Equal(t, int64(50), plan.Freed)
+
+	assert.DirExists(t, filepath.Join(dir, "d"), "a plan should not change the disk")
+	assert.FileExists(t, filepath.Join(dir, "d", "e"))
+
+	plan = NewPlan(&selection, PermanentMode, files.ApparentSize, &protect.Guard{Paths: []string{filepath.Join(dir, "d")}})
+	assert.ErrorIs(t, plan.Entries[1].Problem, protect.ErrProtected)
+	assert.Zero(t, plan.NumFiles)
+}
+
+func TestPlanPermissions(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Getuid() == 0 {
+		t.Skip("permissions are only checked on linux without root")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d", "f"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "f", "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "d", "f"), 0555))
+	defer os.Chmod(filepath.Join(dir, "d", "f"), 0755)
+
+	selection := Selection{Files: []*files.File{files.FindTestFile(root, "d")}}
+	plan := NewPlan(&selection, PermanentMode, files.ApparentSize, &protect.Guard{})
+	assert.ErrorIs(t, plan.Entries[0].Problem, os.ErrPermission, "the content of the folder cannot be deleted")
+	plan = NewPlan(&selection, MoveToTrashMode, files.ApparentSize, &protect.Guard{})
+	assert.NoError(t, plan.Entries[0].Problem, "the folder can be moved to the trash with its content")
+}
@@ -0,0 +1,46 @@
+package cleanup
+
+import (
+	"gocleasy/files"
+)
+
+// Result tells what happened to a selected file when deleting it
+type Result struct {
+	File     *files.File
+	Path     string
+	NumFiles int64 // Files removed, measured in the disk right before deleting them
+	Bytes    int64 // Space in the disk of the files removed
+	Err      error // Why the file could not be deleted, nil if it was
+	Gone     bool  // The file was not in the disk anymore, there was nothing to delete
+}
+
+// Report tells what happened to every selected file in a deletion. Files inside
+// a selected folder are part of the result of the folder
+type Report struct {
+	Mode     Mode
+	Results  []*Result // In the order the files were selected
+	NumFiles int64     // Files removed, including the ones of folders partly deleted
+	Bytes    int64     // Space in the disk of the files removed
+	Failed   int       // Selected files that could not be deleted
+	Gone     int       // Selected files that were not in the disk anymore
+}
+
+// Freed returns the space freed in the disk. Files moved to the trash or to the
+// quarantine still use their space until they are purged
+func (r *Report) Freed() int64 {
+	if r.Mode != PermanentMode {
+		return 0
+	}
+	return r.Bytes
+}
+
+// Failures returns the results of the files that could not be deleted
+func (r *Report) Failures() []*Result {
+	var failures []*Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
@@ -0,0 +1,104 @@
+// Package cleanup keeps the files chosen to be deleted and deletes them, so the
+// window and the terminal interfaces behave the same way
+package cleanup
+
+import (
+	"gocleasy/files"
+)
+
+// Selection stores the files and folders selected to be deleted, in the order
+// they were selected
+type Selection struct {
+	Files []*files.File
+}
+
+// IsSelected checks if the file has been selected
+func (s *Selection) IsSelected(file *files.File) bool {
+	for _, selfile := range s.Files {
+		if selfile == file {
+			return true
+		}
+	}
+	return false
+}
+
+// Select adds the file to the selection, a file is only added once
+func (s *Selection) Select(file *files.File) {
+	if !s.IsSelected(file) {
+		s.Files = append(s.Files, file)
+	}
+}
+
+// Unselect removes the file from the selection
+func (s *Selection) Unselect(file *files.File) {
+	for id, selfile := range s.Files {
+		if selfile == file {
+			s.Files = append(s.Files[:id], s.Files[id+1:]...)
+			return
+		}
+	}
+}
+
+// Toggle selects the file if it was not selected and unselects it otherwise.
+// It returns whether the file ends up selected
+func (s *Selection) Toggle(file *files.File) bool {
+	if s.IsSelected(file) {
+		s.Unselect(file)
+		return false
+	}
+	s.Select(file)
+	return true
+}
+
+// Clear unselects everything
+func (s *Selection) Clear() {
+	s.Files = nil
+}
+
+// Len returns how many files and folders are selected
+func (s *Selection) Len() int {
+	return len(s.Files)
+}
+
+// UnselectInside unselects the files inside the folder, used when its content
+// is not part of the tree anymore
+func (s *Selection) UnselectInside(folder *files.File) {
+	s.keep(func(file *files.File) bool {
+		return !file.IsInside(folder)
+	})
+}
+
+// keep leaves selected only the files accepted by the function
+func (s *Selection) keep(accept func(file *files.File) bool) {
+	var selfiles []*files.File
+	for _, selfile := range s.Files {
+		if accept(selfile) {
+			selfiles = append(selfiles, selfile)
+		}
+	}
+	s.Files = selfiles
+}
+
+// Totals summarizes what deleting the selection means
+type Totals struct {
+	NumFiles int64 // Files deleted, counting the ones inside the folders
+	Size     int64 // Size of everything selected
+	Freed    int64 // Space really freed, without hard links kept outside the selection
+}
+
+// Totals counts the files selected and the space they use with the metric
+func (s *Selection) Totals(metric files.SizeMetric) Totals {
+	var totals Totals
+	for _, file := range s.Files {
+		if file.IsDir {
+			totals.NumFiles += file.NumChildren
+		} else {
+			totals.NumFiles++
+		}
+		totals.Size += file.SizeBy(metric)
+	}
+
+	// Hard linked files only free space when all their links are deleted
+	totals.Freed = files.ReclaimableSize(s.Files, metric)
+	return totals
+}
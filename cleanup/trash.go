@@ -0,0 +1,87 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrTrashUnsupported is returned when files cannot be moved to the trash on this OS
+var ErrTrashUnsupported = errors.New("moving files to the trash is not supported on this OS, delete them permanently instead")
+
+// Extension of the files that describe what is in the trash
+const trashInfoExtension = ".trashinfo"
+
+// Format of the deletion date in the trash info files, in local time
+const trashDateFormat = "2006-01-02T15:04:05"
+
+// homeTrash returns the trash of the user, $XDG_DATA_HOME/Trash
+func homeTrash() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "Trash"), nil
+}
+
+// moveInto moves the file at path into the trash directory, writing first the info
+// file that tells where it was and when it was deleted. infopath is the path saved
+// in the info file, which is relative to the top directory in the trash of a volume.
+// If the name is already in the trash a number is added to it
+func moveInto(trash string, path string, infopath string, deleted time.Time) error {
+	filesdir, infodir := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, dir := range []string{filesdir, infodir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	name := filepath.Base(path)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s.%d", name, i)
+		}
+		// Creating the info file reserves the name in the trash
+		infofile := filepath.Join(infodir, candidate+trashInfoExtension)
+		info, err := os.OpenFile(infofile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		trashed := filepath.Join(filesdir, candidate)
+		if _, err := os.Lstat(trashed); err == nil {
+			// Left by another program without its info file
+			info.Close()
+			continue
+		}
+
+		_, err = info.WriteString(trashInfo(infopath, deleted))
+		if closeErr := info.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, trashed)
+		}
+		if err != nil {
+			os.Remove(infofile)
+			return err
+		}
+		return nil
+	}
+}
+
+// trashInfo returns the content of the info file of a file in the trash
+func trashInfo(path string, deleted time.Time) string {
+	escaped := (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath()
+	return fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, deleted.Format(trashDateFormat))
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !freebsd && !netbsd && !openbsd
+
+package cleanup
+
+// trashSupported tells if files can be moved to the trash on this OS
+const trashSupported = false
+
+// MoveToTrash moves the file or folder to the trash, which is not supported on this OS
+func MoveToTrash(path string) error {
+	return ErrTrashUnsupported
+}
@@ -0,0 +1,71 @@
+package cleanup
+
+import (
+	"gocleasy/files"
+	"gocleasy/protect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashInfo(t *testing.T) {
+	deleted := time.Date(2004, 8, 31, 22, 32, 8, 0, time.Local)
+	assert.Equal(t, "[Trash Info]\nPath=/home/user/a%20file%25\nDeletionDate=2004-08-31T22:32:08\n", trashInfo("/home/user/a file%", deleted))
+}
+
+func TestMoveInto(t *testing.T) {
+	dir, trash := t.TempDir(), t.TempDir()
+	deleted := time.Date(2004, 8, 31, 22, 32, 8, 0, time.Local)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), []byte{byte(i)}, 0644))
+		assert.NoError(t, moveInto(trash, filepath.Join(dir, "c"), "x/c", deleted))
+		assert.NoFileExists(t, filepath.Join(dir, "c"))
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(trash, "files", "c.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, content, "a name already in the trash should get a number")
+	info, err := ioutil.ReadFile(filepath.Join(trash, "info", "c.2"+trashInfoExtension))
+	assert.NoError(t, err)
+	assert.Equal(t, trashInfo("x/c", deleted), string(info))
+
+	assert.Error(t, moveInto(trash, filepath.Join(dir, "missing"), "x/missing", deleted))
+	assert.NoFileExists(t, filepath.Join(trash, "info", "missing"+trashInfoExtension), "the info file should be removed if the file cannot be moved")
+}
+
+func TestDeleteToTrash(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash is only checked on linux")
+	}
+	data, dir := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+
+	selection := Selection{Files: []*files.File{files.FindTestFile(root, "d")}}
+	report := Delete(root, &selection, MoveToTrashMode, &protect.Guard{})
+	assert.Equal(t, int64(1), report.NumFiles)
+	assert.Positive(t, report.Bytes)
+	assert.Zero(t, report.Freed(), "the space is freed when the trash is emptied")
+	assert.NoDirExists(t, filepath.Join(dir, "d"))
+	assert.FileExists(t, filepath.Join(data, "Trash", "files", "d", "e"))
+	info, err := ioutil.ReadFile(filepath.Join(data, "Trash", "info", "d"+trashInfoExtension))
+	assert.NoError(t, err)
+	assert.Contains(t, string(info), "Path="+filepath.Join(dir, "d")+"\n")
+}
+
+func TestModes(t *testing.T) {
+	assert.Equal(t, Modes[0], DefaultMode)
+	assert.Contains(t, Modes, QuarantineMode)
+	if trashSupported {
+		assert.Equal(t, MoveToTrashMode, DefaultMode)
+	} else {
+		assert.NotContains(t, Modes, MoveToTrashMode, "the trash should not be offered where it is not supported")
+	}
+}
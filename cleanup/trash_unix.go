@@ -0,0 +1,140 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// trashSupported tells if files can be moved to the trash on this OS
+const trashSupported = true
+
+// MoveToTrash moves the file or folder to the trash following the freedesktop.org
+// Trash specification, so it can be restored from the file manager. Files in the
+// filesystem of the home folder go to the trash of the user and the rest to the
+// trash in the top directory of their filesystem, because they cannot be moved
+// to another filesystem without copying them
+func MoveToTrash(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	device, err := deviceOf(path)
+	if err != nil {
+		return err
+	}
+
+	home, err := homeTrash()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return err
+	}
+	homedevice, err := deviceOf(home)
+	if err != nil {
+		return err
+	}
+	if device == homedevice {
+		return moveInto(home, path, path, time.Now())
+	}
+
+	topdir, resolved, err := mountPoint(path, device)
+	if err != nil {
+		return err
+	}
+	trash, err := topdirTrash(topdir)
+	if err != nil {
+		return err
+	}
+	infopath, err := filepath.Rel(topdir, resolved)
+	if err != nil {
+		return err
+	}
+	return moveInto(trash, path, infopath, time.Now())
+}
+
+// topdirTrash returns the trash of the filesystem mounted at topdir. The shared
+// $topdir/.Trash is used if the administrator created it with the sticky bit,
+// otherwise the trash of the user is $topdir/.Trash-$uid
+func topdirTrash(topdir string) (string, error) {
+	uid := os.Getuid()
+	shared := filepath.Join(topdir, ".Trash")
+	info, err := os.Lstat(shared)
+	if err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		trash := filepath.Join(shared, fmt.Sprint(uid))
+		if err := os.Mkdir(trash, 0700); err == nil || os.IsExist(err) {
+			if checkUserTrash(trash, uid) == nil {
+				return trash, nil
+			}
+		}
+	}
+
+	trash := filepath.Join(topdir, fmt.Sprintf(".Trash-%d", uid))
+	if err := os.Mkdir(trash, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := checkUserTrash(trash, uid); err != nil {
+		return "", err
+	}
+	return trash, nil
+}
+
+// checkUserTrash checks that the trash is a folder only the user can read, so
+// other users cannot see the deleted files or send them somewhere else with a
+// symbolic link
+func checkUserTrash(trash string, uid int) error {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(trash, &st); err != nil {
+		return &os.PathError{Op: "lstat", Path: trash, Err: err}
+	}
+	switch {
+	case st.Mode&syscall.S_IFMT != syscall.S_IFDIR:
+		return fmt.Errorf("%s is not a folder, cannot move files to the trash", trash)
+	case int(st.Uid) != uid:
+		return fmt.Errorf("%s belongs to another user, cannot move files to the trash", trash)
+	case st.Mode&0777 != 0700:
+		return fmt.Errorf("%s can be used by other users, cannot move files to the trash", trash)
+	}
+	return nil
+}
+
+// mountPoint returns the top directory of the filesystem containing path, and path
+// with the symbolic links of its folders resolved so it is inside the top directory
+func mountPoint(path string, device uint64) (string, string, error) {
+	// Folders reached through symbolic links may be in other filesystems
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", "", err
+	}
+	path = filepath.Join(parent, filepath.Base(path))
+	resolved := path
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, resolved, nil
+		}
+		parentdevice, err := deviceOf(parent)
+		if err != nil {
+			return "", "", err
+		}
+		if parentdevice != device {
+			return path, resolved, nil
+		}
+		path = parent
+	}
+}
+
+// deviceOf returns the device of the filesystem containing path, without following
+// symbolic links
+func deviceOf(path string) (uint64, error) {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return 0, &os.PathError{Op: "lstat", Path: path, Err: err}
+	}
+	return uint64(st.Dev), nil
+}
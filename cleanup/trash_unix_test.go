@@ -0,0 +1,72 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopdirTrash(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash is only checked on linux")
+	}
+	topdir := t.TempDir()
+	trash, err := topdirTrash(topdir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(topdir, fmt.Sprintf(".Trash-%d", os.Getuid())), trash)
+	info, err := os.Stat(trash)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	assert.NoError(t, os.Chmod(trash, 0755))
+	_, err = topdirTrash(topdir)
+	assert.Error(t, err, "a trash other users can read should not be used")
+
+	assert.NoError(t, os.Remove(trash))
+	assert.NoError(t, os.Symlink(t.TempDir(), trash))
+	_, err = topdirTrash(topdir)
+	assert.Error(t, err, "a symbolic link should not be followed")
+
+	if os.Getuid() == 0 {
+		assert.NoError(t, os.Remove(trash))
+		assert.NoError(t, os.Mkdir(trash, 0700))
+		assert.NoError(t, os.Chown(trash, 65534, 65534))
+		_, err = topdirTrash(topdir)
+		assert.Error(t, err, "a trash of another user should not be used")
+	}
+}
+
+func TestMountPointSymlink(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the mount points are only checked on linux")
+	}
+	dir := t.TempDir()
+	shmdevice, err := deviceOf("/dev/shm")
+	tmpdevice, _ := deviceOf(dir)
+	if err != nil || shmdevice == tmpdevice {
+		t.Skip("needs /dev/shm mounted apart from the temporary folder")
+	}
+	other, err := os.MkdirTemp("/dev/shm", "gocleasy")
+	if err != nil {
+		t.Skip("cannot write in /dev/shm")
+	}
+	defer os.RemoveAll(other)
+	assert.NoError(t, os.WriteFile(filepath.Join(other, "c"), []byte("c"), 0o644))
+	assert.NoError(t, os.Symlink(other, filepath.Join(dir, "link")))
+
+	path := filepath.Join(dir, "link", "c")
+	device, err := deviceOf(path)
+	assert.NoError(t, err)
+	topdir, resolved, err := mountPoint(path, device)
+	assert.NoError(t, err)
+	shm, err := filepath.EvalSymlinks("/dev/shm")
+	assert.NoError(t, err)
+	assert.Equal(t, shm, topdir, "the top directory should be the one of the filesystem the link points to")
+	assert.Equal(t, filepath.Join(other, "c"), resolved)
+}
@@ -0,0 +1,122 @@
+// Package cli runs gocleasy from a terminal, without opening a window, so it can
+// be used over SSH or in scripts. It is built as its own program, cmd/gocleasy-cli,
+// which does not need the graphic libraries of the window
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dustin/go-humanize"
+)
+
+// program is the name of the command line program in the help
+const program = "gocleasy-cli"
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0 // Everything went fine
+	ExitError = 1 // The command failed
+	ExitUsage = 2 // The command line is wrong
+)
+
+// command is a subcommand of the command line
+type command struct {
+	name        string
+	usage       string // Arguments of the command
+	description string
+	run         func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"scan", "PATH", "Scan PATH and print the largest files and folders", runScan},
+	{"report", "SNAPSHOT", "Print the largest files and folders of a snapshot or an ncdu export", runReport},
+	{"delete", "PATH...", "Move PATH to the trash, or check what would be deleted with --dry-run", runDelete},
+	{"tui", "PATH", "Scan PATH and select the files to delete with the keyboard", runTUI},
+}
+
+// Run runs the subcommand in args[0] with the rest of the arguments and returns
+// the exit code of the program
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return ExitUsage
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+	usage(stderr)
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return ExitOK
+	}
+	return ExitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s COMMAND [ARGS] [FLAGS]\n", program)
+	fmt.Fprintln(w, "Run gocleasy to open its window instead.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-7s %-9s %s\n", cmd.name, cmd.usage, cmd.description)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Run \"%s COMMAND -h\" to see the flags of a command.\n", program)
+}
+
+// newFlagSet creates the flags of a command, which print their usage to stderr
+func newFlagSet(cmd string, args string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s %s %s [FLAGS]\n", program, cmd, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseArgs parses flags placed before or after the positional arguments, so both
+// "scan --top 10 PATH" and "scan PATH --top 10" work, and returns the positional ones
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// sizeFlag is a flag with a size like 500M or 1G
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	return humanize.Bytes(uint64(*s))
+}
+
+func (s *sizeFlag) Set(value string) error {
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(size)
+	return nil
+}
+
+// isTerminal checks if the file is shown to a person instead of being piped
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"gocleasy/cleanup"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestDir(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), make([]byte, 3000), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 2000), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "d", "f"), make([]byte, 10), 0o644))
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := createTestDir(t)
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"scan", dir, "--top", "2", "--min-size", "1k"}, &stdout, &stderr)
+	assert.Equal(t, ExitOK, code, stderr.String())
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "SIZE")
+	assert.Contains(t, lines[1], "3.0 kB")
+	assert.True(t, strings.HasSuffix(lines[1], filepath.Join(dir, "c")))
+	assert.True(t, strings.HasSuffix(lines[2], filepath.Join(dir, "d")))
+	assert.Contains(t, stderr.String(), "Total: 5.0 kB in 3 files")
+}
+
+func TestScanSaveReport(t *testing.T) {
+	dir := createTestDir(t)
+	saved := filepath.Join(t.TempDir(), "scan.gcsnap")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitOK, Run([]string{"scan", "--quiet", "--save", saved, dir}, &stdout, &stderr), stderr.String())
+
+	var report bytes.Buffer
+	stderr.Reset()
+	assert.Equal(t, ExitOK, Run([]string{"report", saved}, &report, &stderr), stderr.String())
+	assert.Equal(t, stdout.String(), report.String(), "the report of the snapshot should be the same as the scan")
+	assert.Contains(t, stderr.String(), "Snapshot of "+dir)
+}
+
+func TestUsageErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitUsage, Run([]string{"scan"}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"scan", "a", "b"}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"scan", ".", "--symlinks", "sometimes"}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"scan", ".", "--min-size", "big"}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"unknown"}, &stdout, &stderr))
+	assert.Equal(t, ExitOK, Run([]string{"help"}, &stdout, &stderr))
+	assert.Equal(t, ExitError, Run([]string{"scan", filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr))
+	assert.Equal(t, ExitError, Run([]string{"report", filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"tui"}, &stdout, &stderr))
+	assert.Equal(t, ExitUsage, Run([]string{"delete", "--permanent", "--quarantine", "."}, &stdout, &stderr))
+	assert.Equal(t, ExitError, Run([]string{"tui", "."}, &stdout, &stderr), "the terminal interface should not run on a buffer")
+	assert.Empty(t, stdout.String())
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	dir := createTestDir(t)
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"delete", "--dry-run", "--permanent", filepath.Join(dir, "d"), filepath.Join(dir, "c")}, &stdout, &stderr)
+	assert.Equal(t, ExitOK, code, stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasSuffix(lines[1], filepath.Join(dir, "d")))
+	assert.Contains(t, lines[1], " ok ")
+	assert.Equal(t, "Delete permanently: 3 files, 5.0 kB freed, 0 problems", lines[3])
+	assert.FileExists(t, filepath.Join(dir, "c"), "a dry run should not delete anything")
+
+	stdout.Reset()
+	code = Run([]string{"delete", "--dry-run", filepath.Join(dir, "missing")}, &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, Run([]string{"delete", "--permanent", "--quiet", filepath.Join(dir, "d")}, &stdout, &stderr), stderr.String())
+	assert.NoDirExists(t, filepath.Join(dir, "d"))
+	lines = strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], " deleted ")
+	assert.True(t, strings.HasPrefix(lines[2], "Delete permanently: 2 files, "), lines[2])
+}
+
+func TestDeleteNestedPaths(t *testing.T) {
+	dir := createTestDir(t)
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"delete", "--dry-run", "--permanent", filepath.Join(dir, "d"), filepath.Join(dir, "d", "e"), filepath.Join(dir, "d") + "/", dir + "/./d/f"}, &stdout, &stderr)
+	assert.Equal(t, ExitOK, code, stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 3, "paths inside another one should be left out")
+	assert.True(t, strings.HasSuffix(lines[1], filepath.Join(dir, "d")))
+	assert.Equal(t, "Delete permanently: 2 files, 2.0 kB freed, 0 problems", lines[2])
+}
+
+func TestDeleteCancelledScan(t *testing.T) {
+	dir := createTestDir(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout, stderr bytes.Buffer
+	code := deletePaths(ctx, []string{filepath.Join(dir, "d")}, cleanup.PermanentMode, false, true, &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), "nothing was deleted")
+	assert.FileExists(t, filepath.Join(dir, "d", "e"), "a partial scan should not be deleted")
+	assert.FileExists(t, filepath.Join(dir, "d", "f"))
+	assert.Empty(t, stdout.String())
+}
+
+func TestDeleteUnreadable(t *testing.T) {
+	if os.Getuid() == 0 || runtime.GOOS == "windows" {
+		t.Skip("folders are always readable by root")
+	}
+	dir := createTestDir(t)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "d", "locked"), 0o755))
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "d", "locked"), 0o000))
+	defer os.Chmod(filepath.Join(dir, "d", "locked"), 0o755)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitError, Run([]string{"delete", "--permanent", "--quiet", filepath.Join(dir, "d")}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "could not be read completely")
+	assert.FileExists(t, filepath.Join(dir, "d", "e"))
+}
+
+func TestOutermostPaths(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := outermostPaths([]string{filepath.Join(dir, "a", "b"), filepath.Join(dir, "a"), filepath.Join(dir, "ab"), filepath.Join(dir, "a", "..", "a")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a"), filepath.Join(dir, "ab")}, paths)
+}
+
+func TestDeleteProtected(t *testing.T) {
+	home := createTestDir(t)
+	t.Setenv("HOME", home)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitError, Run([]string{"delete", "--permanent", "--quiet", filepath.Join(home, "c"), home}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Refusing to delete: "+home+" is protected")
+	assert.FileExists(t, filepath.Join(home, "c"), "nothing should be deleted if any path is protected")
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gocleasy/cleanup"
+	"gocleasy/files"
+	"gocleasy/protect"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+)
+
+func runDelete(args []string, stdout io.Writer, stderr io.Writer) int {
+	var dryRun, permanent, quarantine, quiet bool
+	flags := newFlagSet("delete", "PATH...", stderr)
+	flags.BoolVar(&dryRun, "dry-run", false, "Print what would be deleted and the problems expected, without deleting anything")
+	flags.BoolVar(&permanent, "permanent", false, "Delete permanently instead of moving to the trash, or to the quarantine where there is no trash")
+	flags.BoolVar(&quarantine, "quarantine", false, "Move to the quarantine of gocleasy instead of the trash, to restore them from the application")
+	flags.BoolVar(&quiet, "quiet", false, "Do not show the progress of the scan")
+	positional, err := parseArgs(flags, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil || len(positional) == 0 || (permanent && quarantine) {
+		flags.Usage()
+		return ExitUsage
+	}
+	mode := cleanup.DefaultMode
+	if permanent {
+		mode = cleanup.PermanentMode
+	} else if quarantine {
+		mode = cleanup.QuarantineMode
+	}
+	return deletePaths(context.Background(), positional, mode, dryRun, quiet, stdout, stderr)
+}
+
+// deletePaths deletes the files at positional the way mode says, or only prints
+// what would happen in a dry run, and returns the exit code of the program
+func deletePaths(ctx context.Context, positional []string, mode cleanup.Mode, dryRun bool, quiet bool, stdout io.Writer, stderr io.Writer) int {
+	// Folders are scanned to know what they contain
+	var roots []*files.File
+	var selection cleanup.Selection
+	paths, err := outermostPaths(positional)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	for _, path := range paths {
+		root, err := statOrScan(ctx, path, quiet, stderr)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitError
+		}
+		roots = append(roots, root)
+		selection.Select(root)
+	}
+
+	guard := protect.Default("")
+	plan := cleanup.NewPlan(&selection, mode, files.ApparentSize, guard)
+	if dryRun {
+		if err := printPlan(stdout, plan); err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitError
+		}
+		if plan.Problems > 0 {
+			return ExitError
+		}
+		return ExitOK
+	}
+
+	// Nothing is deleted if any path is protected
+	protected := false
+	for _, root := range roots {
+		if err := guard.Check(root); err != nil {
+			fmt.Fprintf(stderr, "Refusing to delete: %s\n", err)
+			protected = true
+		}
+	}
+	if protected {
+		return ExitError
+	}
+
+	// Every path is the root of its own tree
+	report := &cleanup.Report{Mode: mode}
+	for _, root := range roots {
+		rootselection := cleanup.Selection{Files: []*files.File{root}}
+		rootreport := cleanup.Delete(root, &rootselection, mode, guard)
+		report.Results = append(report.Results, rootreport.Results...)
+		report.NumFiles += rootreport.NumFiles
+		report.Bytes += rootreport.Bytes
+		report.Failed += rootreport.Failed
+		report.Gone += rootreport.Gone
+	}
+	if err := printDeletionReport(stdout, report); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	if report.Failed > 0 {
+		return ExitError
+	}
+	return ExitOK
+}
+
+// outermostPaths returns the absolute paths to delete, leaving out repeated ones and
+// the ones inside another path, which are deleted with it and would be counted twice
+func outermostPaths(paths []string) ([]string, error) {
+	var abs []string
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		abs = append(abs, path)
+	}
+	var outermost []string
+	for index, path := range abs {
+		inside := false
+		for other, folder := range abs {
+			// Of repeated paths only the first one is kept
+			if isInside(path, folder) || (path == folder && other < index) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			outermost = append(outermost, path)
+		}
+	}
+	return outermost, nil
+}
+
+// isInside checks if path is inside folder
+func isInside(path string, folder string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(folder, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// statOrScan scans the folder at path, or describes the file if it is not a folder.
+// Nothing is deleted if the scan did not see all the content of the folder, the
+// guard could not check it
+func statOrScan(ctx context.Context, path string, quiet bool, stderr io.Writer) (*files.File, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return files.Stat(path)
+	}
+	result := scanFolder(ctx, path, scanFlags{symlinks: "count", quiet: quiet}, stderr)
+	if result.Root.Incomplete {
+		return nil, fmt.Errorf("the scan of %s did not finish, nothing was deleted", path)
+	}
+	for _, scanErr := range result.Errors {
+		// Files vanished while scanning hide nothing
+		if scanErr.Kind != files.Vanished {
+			return nil, fmt.Errorf("%s could not be read completely, nothing was deleted: %w", path, scanErr)
+		}
+	}
+	return result.Root, nil
+}
+
+// printPlan writes what deleting the files would do, one line per path
+func printPlan(w io.Writer, plan *cleanup.Plan) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "SIZE\tFILES\tSTATUS\t PATH")
+	for _, entry := range plan.Entries {
+		status := "ok"
+		switch {
+		case entry.Inside != nil:
+			status = "inside " + entry.Inside.Path()
+		case entry.Problem != nil:
+			status = entry.Problem.Error()
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t %s\n", humanize.Bytes(uint64(entry.Size)), humanize.Comma(entry.NumFiles), status, entry.Path)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s: %s files, %s freed, %d problems\n", plan.Mode, humanize.Comma(plan.NumFiles), humanize.Bytes(uint64(plan.Freed)), plan.Problems)
+	return err
+}
+
+// printDeletionReport writes what happened to every path deleted, with the space
+// measured in the disk
+func printDeletionReport(w io.Writer, report *cleanup.Report) error {
+	done := "deleted"
+	switch report.Mode {
+	case cleanup.MoveToTrashMode:
+		done = "moved to the trash"
+	case cleanup.QuarantineMode:
+		done = "quarantined"
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "SIZE\tFILES\tSTATUS\t PATH")
+	for _, result := range report.Results {
+		status := done
+		switch {
+		case result.Err != nil:
+			status = result.Err.Error()
+		case result.Gone:
+			status = "already gone"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t %s\n", humanize.Bytes(uint64(result.Bytes)), humanize.Comma(result.NumFiles), status, result.Path)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s: %s files, %s, %s freed, %d failed", report.Mode, humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)), humanize.Bytes(uint64(report.Freed())), report.Failed)
+	if report.Gone > 0 {
+		fmt.Fprintf(w, ", %d already gone", report.Gone)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"gocleasy/files"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Output formats of the --format flag
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// record is what is written about every file in JSON and CSV outputs
+type record struct {
+	Path        string     `json:"path"`
+	IsDir       bool       `json:"is_dir"`
+	Size        int64      `json:"size"`
+	DiskUsage   int64      `json:"disk_usage"`
+	NumChildren int64      `json:"num_children"`
+	ModTime     *time.Time `json:"mod_time,omitempty"`
+	AccessTime  *time.Time `json:"access_time,omitempty"`
+}
+
+func newRecord(file *files.File, path string) record {
+	r := record{
+		Path:        path,
+		IsDir:       file.IsDir,
+		Size:        file.Size,
+		DiskUsage:   file.Usage,
+		NumChildren: file.NumChildren,
+	}
+	// Folders show the newest time of what they contain
+	if modTime := file.NewestModTime.Time(); !modTime.IsZero() {
+		r.ModTime = &modTime
+	}
+	if accessTime := file.NewestAccessTime.Time(); !accessTime.IsZero() {
+		r.AccessTime = &accessTime
+	}
+	return r
+}
+
+// treeWalker visits the files to write, up to maxDepth and skipping the small ones
+type treeWalker struct {
+	maxDepth int // Depth of the deepest files written, the root is 0. Negative for no limit
+	minSize  int64
+	metric   files.SizeMetric
+}
+
+// children returns the files of the folder to write, or nil if they are too deep
+func (t treeWalker) children(folder *files.File, depth int) []*files.File {
+	if t.maxDepth >= 0 && depth >= t.maxDepth {
+		return nil
+	}
+	var children []*files.File
+	for _, file := range folder.Files {
+		// Nothing inside a small folder can be big enough
+		if file.SizeBy(t.metric) >= t.minSize {
+			children = append(children, file)
+		}
+	}
+	return children
+}
+
+// walk calls visit for the file and the files inside it in depth-first order,
+// building their paths from the one of the root
+func (t treeWalker) walk(file *files.File, path string, depth int, visit func(*files.File, string) error) error {
+	if err := visit(file, path); err != nil {
+		return err
+	}
+	for _, child := range t.children(file, depth) {
+		if err := t.walk(child, filepath.Join(path, child.Name), depth+1, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONTree writes the tree as nested JSON objects, each folder with its
+// children. Objects are written as they are visited, nothing is buffered
+func writeJSONTree(w io.Writer, root *files.File, t treeWalker) error {
+	bw := bufio.NewWriter(w)
+	if err := t.writeJSONFile(bw, root, root.Path(), 0); err != nil {
+		return err
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+func (t treeWalker) writeJSONFile(bw *bufio.Writer, file *files.File, path string, depth int) error {
+	data, err := json.Marshal(newRecord(file, path))
+	if err != nil {
+		return err
+	}
+	if !file.IsDir {
+		_, err = bw.Write(data)
+		return err
+	}
+	// Add the children before the closing brace of the object
+	bw.Write(data[:len(data)-1])
+	bw.WriteString(`,"children":[`)
+	for i, child := range t.children(file, depth) {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if err := t.writeJSONFile(bw, child, filepath.Join(path, child.Name), depth+1); err != nil {
+			return err
+		}
+	}
+	_, err = bw.WriteString("]}")
+	return err
+}
+
+// writeJSONFlat writes a JSON array with a flat object per file
+func writeJSONFlat(w io.Writer, root *files.File, t treeWalker) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[\n")
+	first := true
+	err := t.walk(root, root.Path(), 0, func(file *files.File, path string) error {
+		data, err := json.Marshal(newRecord(file, path))
+		if err != nil {
+			return err
+		}
+		if !first {
+			bw.WriteString(",\n")
+		}
+		first = false
+		_, err = bw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// writeCSV writes a row per file with a header first
+func writeCSV(w io.Writer, root *files.File, t treeWalker) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "is_dir", "size", "disk_usage", "num_children", "mod_time", "access_time"})
+	err := t.walk(root, root.Path(), 0, func(file *files.File, path string) error {
+		r := newRecord(file, path)
+		return cw.Write([]string{
+			r.Path,
+			strconv.FormatBool(r.IsDir),
+			strconv.FormatInt(r.Size, 10),
+			strconv.FormatInt(r.DiskUsage, 10),
+			strconv.FormatInt(r.NumChildren, 10),
+			formatTime(r.ModTime),
+			formatTime(r.AccessTime),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
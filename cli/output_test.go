@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"gocleasy/files"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOutputTestTree() *files.File {
+	root := files.NewTestFolder("/srv",
+		files.NewTestFile("c", 100),
+		files.NewTestFolder("d",
+			files.NewTestFile("e", 50),
+			files.NewTestFile("f", 5),
+		),
+	)
+	files.FindTestFile(root, "c").ModTime = files.NewUnixNano(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	root.UpdateSize(-1)
+	return root
+}
+
+type jsonTree struct {
+	record
+	Children []jsonTree `json:"children"`
+}
+
+func TestWriteJSONTree(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeJSONTree(&buf, newOutputTestTree(), treeWalker{maxDepth: -1}))
+
+	var tree jsonTree
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &tree))
+	assert.Equal(t, "/srv", tree.Path)
+	assert.Equal(t, int64(155), tree.Size)
+	assert.Len(t, tree.Children, 2)
+	assert.Equal(t, filepath.Join("/srv", "c"), tree.Children[0].Path)
+	assert.Equal(t, "2023-01-02T03:04:05Z", tree.Children[0].ModTime.UTC().Format(time.RFC3339))
+	assert.Equal(t, filepath.Join("/srv", "d", "f"), tree.Children[1].Children[1].Path)
+	assert.Nil(t, tree.Children[1].Children[1].ModTime, "unknown times should be left out")
+}
+
+func TestWriteJSONDepthAndMinSize(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeJSONTree(&buf, newOutputTestTree(), treeWalker{maxDepth: 1}))
+	var tree jsonTree
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &tree))
+	assert.Len(t, tree.Children, 2)
+	assert.Empty(t, tree.Children[1].Children, "folders deeper than the limit should not be written")
+	assert.Equal(t, int64(2), tree.Children[1].NumChildren)
+
+	buf.Reset()
+	assert.NoError(t, writeJSONFlat(&buf, newOutputTestTree(), treeWalker{maxDepth: -1, minSize: 50}))
+	var flat []record
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &flat))
+	paths := []string{}
+	for _, r := range flat {
+		paths = append(paths, r.Path)
+	}
+	assert.Equal(t, []string{"/srv", filepath.Join("/srv", "c"), filepath.Join("/srv", "d"), filepath.Join("/srv", "d", "e")}, paths)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeCSV(&buf, newOutputTestTree(), treeWalker{maxDepth: -1}))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 6)
+	assert.Equal(t, []string{"path", "is_dir", "size", "disk_usage", "num_children", "mod_time", "access_time"}, rows[0])
+	assert.Equal(t, []string{"/srv", "true", "155", "0", "3", "2023-01-02T03:04:05Z", ""}, rows[1])
+	assert.Equal(t, filepath.Join("/srv", "d", "f"), rows[5][0])
+}
+
+func TestScanFormats(t *testing.T) {
+	dir := createTestDir(t)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitOK, Run([]string{"scan", dir, "--format", "csv", "--depth", "1"}, &stdout, &stderr), stderr.String())
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4, "the header, the root and its two children")
+
+	assert.Equal(t, ExitUsage, Run([]string{"scan", dir, "--format", "xml"}, &stdout, &stderr))
+}
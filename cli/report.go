@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/ncdu"
+	"gocleasy/snapshot"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+)
+
+// reportFlags choose which files are printed, how they are measured and in which format
+type reportFlags struct {
+	top       int
+	minSize   sizeFlag
+	diskUsage bool
+	format    string
+	depth     int
+	flat      bool
+}
+
+func (r *reportFlags) register(flags *flag.FlagSet) {
+	flags.IntVar(&r.top, "top", 20, "Number of files and folders to print in the table")
+	flags.Var(&r.minSize, "min-size", "Only print files and folders of at least this size, like 500M or 1G")
+	flags.BoolVar(&r.diskUsage, "disk-usage", false, "Measure the space allocated on disk instead of the apparent size")
+	flags.StringVar(&r.format, "format", formatTable, "Output format: table of the largest files, json or csv with the whole tree")
+	flags.IntVar(&r.depth, "depth", -1, "Levels of folders to write in json and csv outputs, all if negative")
+	flags.BoolVar(&r.flat, "flat", false, "Write the json output as a flat list instead of nested folders")
+}
+
+// valid checks the values that the flag package cannot check
+func (r *reportFlags) valid() bool {
+	return r.format == formatTable || r.format == formatJSON || r.format == formatCSV
+}
+
+func (r *reportFlags) metric() files.SizeMetric {
+	if r.diskUsage {
+		return files.DiskUsage
+	}
+	return files.ApparentSize
+}
+
+// printReport prints the tree in the chosen format
+func printReport(w io.Writer, root *files.File, report reportFlags) error {
+	t := treeWalker{maxDepth: report.depth, minSize: int64(report.minSize), metric: report.metric()}
+	files.SortDescBy(root, report.metric())
+	switch {
+	case report.format == formatCSV:
+		return writeCSV(w, root, t)
+	case report.format == formatJSON && report.flat:
+		return writeJSONFlat(w, root, t)
+	case report.format == formatJSON:
+		return writeJSONTree(w, root, t)
+	default:
+		return printLargest(w, root, report)
+	}
+}
+
+// printLargest prints a table with the largest files and folders of the tree
+func printLargest(w io.Writer, root *files.File, report reportFlags) error {
+	metric := report.metric()
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, "%s\tFILES\t PATH\n", strings.ToUpper(metric.String()))
+	for _, file := range files.Largest(root, report.top, int64(report.minSize), metric) {
+		numchildren := "-"
+		if file.IsDir {
+			numchildren = humanize.Comma(file.NumChildren)
+		}
+		fmt.Fprintf(table, "%s\t%s\t %s\n", humanize.Bytes(uint64(file.SizeBy(metric))), numchildren, file.Path())
+	}
+	return table.Flush()
+}
+
+// printTotal prints the size of the whole tree
+func printTotal(w io.Writer, root *files.File, report reportFlags) {
+	fmt.Fprintf(w, "Total: %s in %s files\n", humanize.Bytes(uint64(root.SizeBy(report.metric()))), humanize.Comma(root.NumChildren))
+}
+
+func runReport(args []string, stdout io.Writer, stderr io.Writer) int {
+	var report reportFlags
+	flags := newFlagSet("report", "SNAPSHOT", stderr)
+	report.register(flags)
+	positional, err := parseArgs(flags, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil || len(positional) != 1 || !report.valid() {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	root, err := loadTree(positional[0], stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	if err := printReport(stdout, root, report); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	printTotal(stderr, root, report)
+	return ExitOK
+}
+
+// loadTree reads a snapshot saved by gocleasy, or an ncdu export if it is a JSON file
+func loadTree(path string, stderr io.Writer) (*files.File, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		export, err := ncdu.ImportFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "ncdu export of %s from %s\n", export.Root.Name, export.Time().Format("2006-01-02 15:04"))
+		return export.Root, nil
+	}
+	loaded, err := snapshot.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(stderr, "Snapshot of %s from %s\n", loaded.Path(), loaded.Time.Format("2006-01-02 15:04"))
+	return loaded.Root, nil
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/ignore"
+	"gocleasy/snapshot"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// symlinkPolicies are the values of the --symlinks flag
+var symlinkPolicies = map[string]files.SymlinkPolicy{
+	"count":  files.CountSymlinks,
+	"skip":   files.SkipSymlinks,
+	"follow": files.FollowSymlinks,
+}
+
+// scanFlags are the flags of the commands that scan a folder
+type scanFlags struct {
+	oneFileSystem bool
+	symlinks      string
+	quiet         bool
+}
+
+func (s *scanFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&s.oneFileSystem, "one-file-system", false, "Do not go into folders of other filesystems, like mount points")
+	flags.StringVar(&s.symlinks, "symlinks", "count", "What to do with symbolic links: count, skip or follow")
+	flags.BoolVar(&s.quiet, "quiet", false, "Do not show the progress of the scan")
+}
+
+func (s *scanFlags) valid() bool {
+	_, ok := symlinkPolicies[s.symlinks]
+	return ok
+}
+
+func (s *scanFlags) options() files.WalkOptions {
+	return files.WalkOptions{OneFileSystem: s.oneFileSystem, Symlinks: symlinkPolicies[s.symlinks]}
+}
+
+func runScan(args []string, stdout io.Writer, stderr io.Writer) int {
+	var report reportFlags
+	var scan scanFlags
+	var save string
+	flags := newFlagSet("scan", "PATH", stderr)
+	report.register(flags)
+	scan.register(flags)
+	flags.StringVar(&save, "save", "", "Save the scan as a snapshot in this file")
+	positional, err := parseArgs(flags, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil || len(positional) != 1 || !scan.valid() || !report.valid() {
+		flags.Usage()
+		return ExitUsage
+	}
+	path := positional[0]
+	if _, err := os.ReadDir(path); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+
+	started := time.Now()
+	result := scanFolder(context.Background(), path, scan, stderr)
+	if save != "" {
+		err := snapshot.Save(save, &snapshot.Snapshot{Root: result.Root, Errors: result.Errors, Time: started, Options: scan.options()})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitError
+		}
+	}
+	if err := printReport(stdout, result.Root, report); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	printTotal(stderr, result.Root, report)
+	return ExitOK
+}
+
+// scanFolder scans path showing the progress and tells if the scan was cancelled
+// with Ctrl+C or had problems
+func scanFolder(ctx context.Context, path string, scan scanFlags, stderr io.Writer) *files.ScanResult {
+	// Ctrl+C stops the scan and keeps what was found so far
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var progress chan files.Progress
+	done := make(chan struct{})
+	if !scan.quiet && isTerminal(stderr) {
+		progress = make(chan files.Progress)
+		go showProgress(stderr, progress, done)
+	} else {
+		close(done)
+	}
+
+	result := files.WalkFolderContext(ctx, path, ioutil.ReadDir, ignore.Default(), progress, scan.options())
+	<-done
+
+	if result.Root.Incomplete {
+		fmt.Fprintln(stderr, "Scan cancelled, the results are incomplete")
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(stderr, "%s problems found while scanning\n", humanize.Comma(int64(len(result.Errors))))
+	}
+	return result
+}
+
+// showProgress keeps a line updated with how far the scan has gone, until the
+// progress channel is closed
+func showProgress(w io.Writer, progress <-chan files.Progress, done chan<- struct{}) {
+	defer close(done)
+	var shown bool
+	for event := range progress {
+		fmt.Fprintf(w, "\r\033[KScanned %s folders, %s files, %s",
+			humanize.Comma(event.DirsScanned), humanize.Comma(event.FilesCounted), humanize.Bytes(uint64(event.Bytes)))
+		shown = true
+	}
+	if shown {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/tui"
+	"io"
+	"os"
+)
+
+func runTUI(args []string, stdout io.Writer, stderr io.Writer) int {
+	var scan scanFlags
+	var diskUsage bool
+	flags := newFlagSet("tui", "PATH", stderr)
+	scan.register(flags)
+	flags.BoolVar(&diskUsage, "disk-usage", false, "Measure the space allocated on disk instead of the apparent size")
+	positional, err := parseArgs(flags, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil || len(positional) != 1 || !scan.valid() {
+		flags.Usage()
+		return ExitUsage
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(stdout) {
+		fmt.Fprintln(stderr, "The terminal interface needs to be run in a terminal")
+		return ExitError
+	}
+	path := positional[0]
+	if _, err := os.ReadDir(path); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+
+	result := scanFolder(context.Background(), path, scan, stderr)
+	metric := files.ApparentSize
+	if diskUsage {
+		metric = files.DiskUsage
+	}
+	files.SortDescBy(result.Root, metric)
+	if err := tui.Run(result.Root, metric, os.Stdin, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+	return ExitOK
+}
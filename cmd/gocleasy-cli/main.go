@@ -0,0 +1,12 @@
+// Command gocleasy-cli runs gocleasy in a terminal, over SSH or in scripts. It is
+// kept apart from the window so it runs where there are no graphic libraries
+package main
+
+import (
+	"gocleasy/cli"
+	"os"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// arenaBlockSize is the number of files allocated at once by an arena
+const arenaBlockSize = 4096
+
+// arena allocates files in big blocks shared by all the goroutines of a scan, so
+// millions of files need a few thousand allocations. A block is only freed when
+// none of its files is used anymore
+type arena struct {
+	mutex sync.Mutex
+	block []File // Files of the current block not given yet
+}
+
+// newFile returns a zeroed file from the arena, or a new one if the arena is nil
+func (a *arena) newFile() *File {
+	if a == nil {
+		return &File{}
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.block) == 0 {
+		a.block = make([]File, arenaBlockSize)
+	}
+	file := &a.block[0]
+	a.block = a.block[1:]
+	return file
+}
+
+// Shards of a name interner and names kept by each one. Walker goroutines rarely
+// wait for each other with many shards, and the names kept are bounded so unique
+// names, the most common, do not take as much memory as the tree itself
+const (
+	internerShards    = 64
+	internerShardSize = 1024
+)
+
+// nameInterner makes files with the same name, like index.js or .git, share the
+// memory of the name. Only the names seen lately are shared, a shard forgets its
+// names when it is full
+type nameInterner struct {
+	seed   maphash.Seed
+	shards [internerShards]internerShard
+}
+
+type internerShard struct {
+	mutex sync.Mutex
+	names map[string]string
+}
+
+func newNameInterner() *nameInterner {
+	return &nameInterner{seed: maphash.MakeSeed()}
+}
+
+func (n *nameInterner) intern(name string) string {
+	shard := &n.shards[maphash.String(n.seed, name)%internerShards]
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if interned, ok := shard.names[name]; ok {
+		return interned
+	}
+	if len(shard.names) >= internerShardSize || shard.names == nil {
+		shard.names = make(map[string]string, internerShardSize)
+	}
+	shard.names[name] = name
+	return name
+}
@@ -0,0 +1,92 @@
+package files
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkFolderArena(t *testing.T) {
+	testStructure := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+				{"f", 30, []fakeFile{}},
+			}},
+		}},
+	}}
+	noIgnore := func(string) bool { return false }
+	expected := WalkFolder("b", createReadDir(testStructure), noIgnore, nil, WalkOptions{}).Root
+	result := WalkFolder("b", createReadDir(testStructure), noIgnore, nil, WalkOptions{Arena: true}).Root
+	assert.Equal(t, expected, result, "the arena should not change the tree")
+	assert.Nil(t, FindTestFile(result, "c").Files, "files should not have children")
+}
+
+func TestArenaNewFile(t *testing.T) {
+	var nilArena *arena
+	assert.Equal(t, &File{}, nilArena.newFile())
+
+	a := &arena{}
+	first, second := a.newFile(), a.newFile()
+	assert.NotSame(t, first, second)
+	for i := 0; i < arenaBlockSize; i++ {
+		a.newFile()
+	}
+	assert.Equal(t, File{}, *a.newFile(), "new blocks should be allocated when one is used up")
+}
+
+func TestNameInterner(t *testing.T) {
+	names := newNameInterner()
+	first := names.intern(string([]byte("index.js")))
+	second := names.intern(string([]byte("index.js")))
+	assert.Equal(t, "index.js", second)
+	assert.Same(t, unsafe.StringData(first), unsafe.StringData(second), "equal names should share memory")
+
+	// Shards forget their names when they are full, so the memory kept is bounded
+	for i := 0; i < 2*internerShards*internerShardSize; i++ {
+		names.intern(fmt.Sprintf("file%d", i))
+	}
+	for i := range names.shards {
+		assert.LessOrEqual(t, len(names.shards[i].names), internerShardSize)
+	}
+}
+
+// legacyFile has the layout files had when the path and the times were stored in
+// every file, to compare the memory they take now
+type legacyFile struct {
+	Name             string
+	Size             int64
+	Usage            int64
+	IsDir            bool
+	Files            []*legacyFile
+	Parent           *legacyFile
+	FullPath         string
+	Level            int
+	NumChildren      int64
+	Incomplete       bool
+	Unreadable       bool
+	Device           uint64
+	Inode            uint64
+	NumLinks         uint64
+	LinkType         int
+	LinkTarget       string
+	ModTime          time.Time
+	AccessTime       time.Time
+	NewestModTime    time.Time
+	NewestAccessTime time.Time
+	Uid              uint32
+	Gid              uint32
+	Mode             uint32
+}
+
+func TestFileLayout(t *testing.T) {
+	assert.Less(t, int(unsafe.Sizeof(File{})), int(unsafe.Sizeof(legacyFile{})))
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		assert.Equal(t, uintptr(176), unsafe.Sizeof(File{}))
+		assert.Equal(t, uintptr(272), unsafe.Sizeof(legacyFile{}))
+	}
+}
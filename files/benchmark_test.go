@@ -0,0 +1,129 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// syntheticInfo describes the files of a synthetic tree
+type syntheticInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (s syntheticInfo) Name() string       { return s.name }
+func (s syntheticInfo) Size() int64        { return s.size }
+func (s syntheticInfo) Mode() os.FileMode  { return 0 }
+func (s syntheticInfo) ModTime() time.Time { return time.Time{} }
+func (s syntheticInfo) IsDir() bool        { return s.isDir }
+func (s syntheticInfo) Sys() interface{}   { return nil }
+
+// syntheticReadDir lists a tree with fanout folders per folder until depth,
+// where each folder has fanout files. Fanout 100 and depth 2 make about a million nodes
+func syntheticReadDir(fanout int, depth int) ReadDir {
+	folders := make([]os.FileInfo, fanout)
+	leaves := make([]os.FileInfo, fanout)
+	for i := 0; i < fanout; i++ {
+		folders[i] = syntheticInfo{name: fmt.Sprintf("d%03d", i), isDir: true}
+		leaves[i] = syntheticInfo{name: fmt.Sprintf("f%03d", i), size: int64(i * 1000)}
+	}
+	return func(path string) ([]os.FileInfo, error) {
+		if strings.Count(path, string(filepath.Separator)) < depth {
+			return folders, nil
+		}
+		return leaves, nil
+	}
+}
+
+func benchmarkWalkFolder(b *testing.B, options WalkOptions) {
+	readDir := syntheticReadDir(100, 2)
+	noIgnore := func(string) bool { return false }
+	var stats runtime.MemStats
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before := stats.HeapAlloc
+
+		root := WalkFolder("r", readDir, noIgnore, nil, options).Root
+
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		retained += stats.HeapAlloc - before
+		if root.NumChildren != 1000000 {
+			b.Fatalf("unexpected number of children %d", root.NumChildren)
+		}
+	}
+	// Memory used by the tree once the scan has finished
+	b.ReportMetric(float64(retained)/float64(b.N)/(1<<20), "MiB/tree")
+	b.ReportMetric(float64(unsafe.Sizeof(File{})), "B/file")
+}
+
+// legacyTree builds the files listed by readDir with the layout files had before
+// they were made compact, like the walker did then
+func legacyTree(path string, name string, readDir ReadDir) *legacyFile {
+	folder := &legacyFile{Name: name, FullPath: path, IsDir: true}
+	entries, _ := readDir(path)
+	for _, entry := range entries {
+		child := &legacyFile{
+			Name:     entry.Name(),
+			FullPath: filepath.Join(path, entry.Name()),
+			Size:     entry.Size(),
+			Parent:   folder,
+			Level:    folder.Level + 1,
+		}
+		if entry.IsDir() {
+			child = legacyTree(child.FullPath, entry.Name(), readDir)
+			child.Parent, child.Level = folder, folder.Level+1
+		}
+		folder.Files = append(folder.Files, child)
+		if entry.IsDir() {
+			folder.NumChildren += child.NumChildren
+		} else {
+			folder.NumChildren++
+		}
+	}
+	return folder
+}
+
+// Compares the scan time and memory of a synthetic million-node tree, with the
+// memory the same tree took with the layout files had before
+func BenchmarkWalkFolderMillionNodes(b *testing.B) {
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		readDir := syntheticReadDir(100, 2)
+		var stats runtime.MemStats
+		var retained uint64
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			before := stats.HeapAlloc
+
+			root := legacyTree("r", "r", readDir)
+
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			retained += stats.HeapAlloc - before
+			if root.NumChildren != 1000000 {
+				b.Fatalf("unexpected number of children %d", root.NumChildren)
+			}
+		}
+		b.ReportMetric(float64(retained)/float64(b.N)/(1<<20), "MiB/tree")
+		b.ReportMetric(float64(unsafe.Sizeof(legacyFile{})), "B/file")
+	})
+	b.Run("default", func(b *testing.B) {
+		b.ReportAllocs()
+		benchmarkWalkFolder(b, WalkOptions{})
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		benchmarkWalkFolder(b, WalkOptions{Arena: true})
+	})
+}
@@ -0,0 +1,102 @@
+package files
+
+import (
+	"sort"
+)
+
+// ChangeKind tells how a file changed between two scans
+type ChangeKind int
+
+const (
+	Unchanged ChangeKind = iota // Same size in both scans
+	Added                       // Only in the new scan
+	Removed                     // Only in the old scan
+	Grown                       // Bigger in the new scan
+	Shrunk                      // Smaller in the new scan
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Grown:
+		return "grown"
+	case Shrunk:
+		return "shrunk"
+	default:
+		return "unchanged"
+	}
+}
+
+// Delta is the change of a file or folder between two scans of the same path
+type Delta struct {
+	Name     string     // Name of the file
+	IsDir    bool       // To indicate if the file is a folder or not
+	Kind     ChangeKind // How the file changed
+	OldSize  int64      // Size in the old scan, 0 if it was added
+	NewSize  int64      // Size in the new scan, 0 if it was removed
+	Old      *File      // File in the old scan, nil if it was added
+	New      *File      // File in the new scan, nil if it was removed
+	Children []*Delta   // Changes inside the folder, the ones that grew most first. Unchanged files are left out
+}
+
+// Growth returns how much the file grew, negative if it shrunk
+func (d *Delta) Growth() int64 {
+	return d.NewSize - d.OldSize
+}
+
+// Diff compares two scans of the same path and returns the changes from old to new,
+// measuring files with the given metric. Files are matched by name in each folder
+func Diff(old *File, new *File, metric SizeMetric) *Delta {
+	delta := &Delta{
+		Name:    new.Name,
+		IsDir:   new.IsDir,
+		OldSize: old.SizeBy(metric),
+		NewSize: new.SizeBy(metric),
+		Old:     old,
+		New:     new,
+	}
+	switch {
+	case delta.NewSize > delta.OldSize:
+		delta.Kind = Grown
+	case delta.NewSize < delta.OldSize:
+		delta.Kind = Shrunk
+	}
+	if !old.IsDir || !new.IsDir {
+		// A file replaced by a folder, or the other way round, is only compared by size
+		return delta
+	}
+
+	oldFiles := make(map[string]*File, len(old.Files))
+	for _, file := range old.Files {
+		oldFiles[file.Name] = file
+	}
+	for _, file := range new.Files {
+		if oldFile, ok := oldFiles[file.Name]; ok {
+			delete(oldFiles, file.Name)
+			child := Diff(oldFile, file, metric)
+			if child.Kind != Unchanged || len(child.Children) > 0 {
+				delta.Children = append(delta.Children, child)
+			}
+		} else {
+			delta.Children = append(delta.Children, &Delta{
+				Name: file.Name, IsDir: file.IsDir, Kind: Added, NewSize: file.SizeBy(metric), New: file,
+			})
+		}
+	}
+	for _, file := range oldFiles {
+		delta.Children = append(delta.Children, &Delta{
+			Name: file.Name, IsDir: file.IsDir, Kind: Removed, OldSize: file.SizeBy(metric), Old: file,
+		})
+	}
+
+	sort.Slice(delta.Children, func(i, j int) bool {
+		if delta.Children[i].Growth() != delta.Children[j].Growth() {
+			return delta.Children[i].Growth() > delta.Children[j].Growth()
+		}
+		return delta.Children[i].Name < delta.Children[j].Name
+	})
+	return delta
+}
@@ -0,0 +1,59 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := NewTestFolder("a",
+		NewTestFile("same", 100),
+		NewTestFile("removed", 30),
+		NewTestFolder("b",
+			NewTestFile("grown", 10),
+			NewTestFile("shrunk", 50),
+		),
+	)
+	new := NewTestFolder("a",
+		NewTestFile("same", 100),
+		NewTestFolder("b",
+			NewTestFile("grown", 500),
+			NewTestFile("shrunk", 20),
+		),
+		NewTestFile("added", 40),
+	)
+	delta := Diff(old, new, ApparentSize)
+
+	assert.Equal(t, Grown, delta.Kind)
+	assert.Equal(t, int64(190), delta.OldSize)
+	assert.Equal(t, int64(660), delta.NewSize)
+	assert.Equal(t, int64(470), delta.Growth())
+
+	names := []string{}
+	for _, child := range delta.Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"b", "added", "removed"}, names, "changes should be sorted by growth, unchanged files left out")
+
+	b := delta.Children[0]
+	assert.Equal(t, Grown, b.Kind)
+	assert.Equal(t, int64(460), b.Growth())
+	assert.Equal(t, "grown", b.Children[0].Name)
+	assert.Equal(t, Shrunk, b.Children[1].Kind)
+	assert.Equal(t, int64(-30), b.Children[1].Growth())
+
+	assert.Equal(t, Added, delta.Children[1].Kind)
+	assert.Nil(t, delta.Children[1].Old)
+	assert.Equal(t, Removed, delta.Children[2].Kind)
+	assert.Equal(t, int64(-30), delta.Children[2].Growth())
+	assert.Nil(t, delta.Children[2].New)
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	old := NewTestFolder("a", NewTestFolder("b", NewTestFile("c", 10)))
+	new := NewTestFolder("a", NewTestFolder("b", NewTestFile("c", 10)))
+	delta := Diff(old, new, ApparentSize)
+	assert.Equal(t, Unchanged, delta.Kind)
+	assert.Empty(t, delta.Children)
+}
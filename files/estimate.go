@@ -0,0 +1,49 @@
+package files
+
+import (
+	"time"
+)
+
+// ScanEstimate is what the filesystem says is stored in it, used to know how far a
+// scan of the whole filesystem has gone
+type ScanEstimate struct {
+	UsedBytes  int64 // Bytes allocated in the filesystem
+	UsedInodes int64 // Files and folders in the filesystem, 0 if unknown
+}
+
+// Fraction returns how much of the filesystem the scan has already gone through, from 0 to 1.
+// The scan may find more than the estimate (other mounts, hard links...) so it
+// never reaches 1 until the scan is finished
+func (e ScanEstimate) Fraction(p Progress) float64 {
+	var fractions []float64
+	if e.UsedBytes > 0 {
+		fractions = append(fractions, float64(p.Usage)/float64(e.UsedBytes))
+	}
+	if e.UsedInodes > 0 {
+		fractions = append(fractions, float64(p.DirsScanned+p.FilesCounted)/float64(e.UsedInodes))
+	}
+	if len(fractions) == 0 {
+		return 0
+	}
+	var fraction float64
+	for _, f := range fractions {
+		fraction += f
+	}
+	fraction /= float64(len(fractions))
+	if fraction > maxFraction {
+		return maxFraction
+	}
+	return fraction
+}
+
+// Never say that a scan in progress is finished
+const maxFraction = 0.99
+
+// Remaining estimates how long the scan will take to finish given how long it has been running
+func (e ScanEstimate) Remaining(p Progress, elapsed time.Duration) time.Duration {
+	fraction := e.Fraction(p)
+	if fraction <= 0 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+}
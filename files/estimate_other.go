@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package files
+
+// EstimateScan returns what is stored in the filesystem mounted at path.
+// Filesystem statistics are not read on this OS so it is never available
+func EstimateScan(path string) (ScanEstimate, bool) {
+	return ScanEstimate{}, false
+}
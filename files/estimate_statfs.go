@@ -0,0 +1,46 @@
+//go:build linux || darwin
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// EstimateScan returns what is stored in the filesystem mounted at path. The estimate
+// is only meaningful when the whole filesystem is scanned, so it is not available
+// when path is not the root of a mount
+func EstimateScan(path string) (ScanEstimate, bool) {
+	if !isMountRoot(path) {
+		return ScanEstimate{}, false
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ScanEstimate{}, false
+	}
+	return ScanEstimate{
+		UsedBytes:  int64(uint64(st.Blocks)-uint64(st.Bfree)) * blockSize(&st),
+		UsedInodes: int64(uint64(st.Files) - uint64(st.Ffree)),
+	}, true
+}
+
+// isMountRoot reports whether path is the root of a mounted filesystem,
+// that is, its parent is in another device or it has no parent
+func isMountRoot(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	parentInfo, err := os.Stat(filepath.Join(path, ".."))
+	if err != nil {
+		return false
+	}
+	if os.SameFile(info, parentInfo) {
+		// Only "/" is its own parent
+		return true
+	}
+	device, _, _ := inodeInfo(info)
+	parentDevice, _, _ := inodeInfo(parentInfo)
+	return device != parentDevice
+}
@@ -0,0 +1,35 @@
+package files
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanEstimateFraction(t *testing.T) {
+	estimate := ScanEstimate{UsedBytes: 1000, UsedInodes: 100}
+	assert.InDelta(t, 0.35, estimate.Fraction(Progress{Usage: 500, DirsScanned: 5, FilesCounted: 15}), 0.001)
+	assert.Equal(t, 0.99, estimate.Fraction(Progress{Usage: 2000, FilesCounted: 200}), "a scan in progress should never be complete")
+	assert.Equal(t, 0.5, ScanEstimate{UsedBytes: 1000}.Fraction(Progress{Usage: 500, FilesCounted: 200}), "unknown inodes should be ignored")
+	assert.Equal(t, 0.0, ScanEstimate{}.Fraction(Progress{Usage: 500}))
+}
+
+func TestScanEstimateRemaining(t *testing.T) {
+	estimate := ScanEstimate{UsedBytes: 1000}
+	assert.Equal(t, 3*time.Minute, estimate.Remaining(Progress{Usage: 250}, time.Minute))
+	assert.Equal(t, time.Duration(0), estimate.Remaining(Progress{}, time.Minute))
+}
+
+func TestEstimateScan(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("filesystem statistics are only checked on linux")
+	}
+	estimate, ok := EstimateScan("/")
+	assert.True(t, ok, "the root folder is always a mount root")
+	assert.Greater(t, estimate.UsedBytes, int64(0))
+
+	_, ok = EstimateScan(t.TempDir())
+	assert.False(t, ok, "a folder inside a filesystem has no estimate")
+}
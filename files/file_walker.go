@@ -1,44 +1,103 @@
 package files
 
 import (
-	"log"
+	"context"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 
 	"gioui.org/widget"
 )
 
-// File structure representing files and folders with their accumulated sizes
+// File structure representing files and folders with their accumulated sizes.
+// There is one per file of the scanned disk, so fields are ordered to avoid padding
+// and the path is not stored, it is built from the parent chain by Path
 type File struct {
-	Name        string  // Name of the file
+	Name        string  // Name of the file, the scanned path for the root
 	Size        int64   // Size of the file or directory
-	IsDir       bool    // To indicate if the file is a folder or not
-	Files       []*File // Files that contain in case IsDir == true.
-	FullPath    string  // Path of the file
+	Usage       int64   // Space allocated on disk for the file or directory
+	Files       []*File // Files that contain in case IsDir == true, nil for files
+	Parent      *File   // Folder that contains the file, nil for the root
 	Level       int     // Indicates in which level the file is compared with the root level
 	NumChildren int64   // Num of files that the directory contains
+	Device      uint64  // Device that contains the file
+	Inode       uint64  // Inode of the file in its device
+	NumLinks    uint64  // Number of hard links pointing to the inode
+	LinkTarget  string  // Path the symbolic link points to
+
+	ModTime          UnixNano    // Last modification of the file
+	AccessTime       UnixNano    // Last time the file was read
+	NewestModTime    UnixNano    // Most recent ModTime of the file or anything inside the folder
+	NewestAccessTime UnixNano    // Most recent AccessTime of the file or anything inside the folder
+	Uid              uint32      // User owning the file
+	Gid              uint32      // Group owning the file
+	Mode             os.FileMode // Type and permission bits of the file
+	LinkType         LinkType    // Whether the file is a symbolic link
+
+	IsDir          bool // To indicate if the file is a folder or not
+	Incomplete     bool // The scan was cancelled before the folder was fully read
+	Unreadable     bool // The folder could not be read, its size is unknown
+	SlashSeparated bool // Set in the root of trees of an fs.FS, whose paths are slash separated on every OS
 }
 
 // This allows to reduce RAM usage. We only have widget.Bools for shown files instead of the whole filesystem
 type FileShow struct {
-	File         *File       // Points to a file
-	IsSelected   widget.Bool // Indicate if the file has been selected
-	ActionButton widget.Bool // Indicate if the folder has to be opened/closed
+	File          *File            // Points to a file
+	IsSelected    widget.Bool      // Indicate if the file has been selected
+	ActionButton  widget.Bool      // Indicate if the folder has to be opened/closed
+	RefreshButton widget.Clickable // Indicate if the folder has to be scanned again
 }
 
-// UpdateSize goes through subfiles and subfolders and accumulates their size
+// UpdateSize goes through subfiles and subfolders and accumulates their size.
+// Files reachable through several hard links are only counted the first time,
+// and folders reached through several followed symbolic links only at their real
+// place, or through the first link if they are not in the tree
 func (f *File) UpdateSize(level int) {
+	folders := map[inodeKey]struct{}{}
+	f.collectFolders(folders)
+	f.updateSize(level, map[inodeKey]struct{}{}, folders)
+}
+
+func (f *File) updateSize(level int, seen map[inodeKey]struct{}, folders map[inodeKey]struct{}) {
+	f.NewestModTime, f.NewestAccessTime = f.ModTime, f.AccessTime
 	if !f.IsDir {
 		return
 	}
-	var size int64
+	var size, usage int64
 	var numchildren int64
+	// The folders are read concurrently, go through the children by name so the
+	// bytes of a hard linked file always go to the same link
+	sort.Slice(f.Files, func(i, j int) bool {
+		return f.Files[i].Name < f.Files[j].Name
+	})
 	for _, child := range f.Files {
-		child.UpdateSize(level + 1)
+		child.Parent = f
+		if child.isFollowedAgain(seen, folders) {
+			// Its content is shown but it was already counted somewhere else
+			child.updateSize(level+1, map[inodeKey]struct{}{}, folders)
+			f.updateNewest(child)
+			numchildren++
+			continue
+		}
+		child.updateSize(level+1, seen, folders)
+		f.updateNewest(child)
+		if child.IsHardLinked() {
+			key := child.inodeKey()
+			if _, ok := seen[key]; ok {
+				// Already counted through another link
+				numchildren++
+				continue
+			}
+			seen[key] = struct{}{}
+		}
 		size += child.Size
+		usage += child.Usage
+		if child.Incomplete {
+			f.Incomplete = true
+		}
 		if child.IsDir {
 			numchildren += child.NumChildren
 		} else {
@@ -46,11 +105,12 @@ func (f *File) UpdateSize(level int) {
 		}
 	}
 	f.Size = size
+	f.Usage = usage
 	f.Level = level
 	f.NumChildren = numchildren
 
-	// Sort files
-	sort.Slice(f.Files, func(i, j int) bool {
+	// Sort files, keeping the order by name of the ones with the same size
+	sort.SliceStable(f.Files, func(i, j int) bool {
 		return f.Files[i].Size > f.Files[j].Size
 	})
 }
@@ -61,102 +121,285 @@ type ReadDir func(dirname string) ([]os.FileInfo, error)
 // ShouldIgnoreFolder function decides whether a folder should be ignored
 type ShouldIgnoreFolder func(absolutePath string) bool
 
-func ignoringReadDir(shouldIgnore ShouldIgnoreFolder, originalReadDir ReadDir) ReadDir {
-	return func(path string) ([]os.FileInfo, error) {
-		if shouldIgnore(path) {
-			return []os.FileInfo{}, nil
-		}
-		return originalReadDir(path)
-	}
+// WalkOptions changes how WalkFolder goes through the folders
+type WalkOptions struct {
+	OneFileSystem bool          // Do not go into folders of other filesystems, like mount points
+	Symlinks      SymlinkPolicy // What to do with symbolic links
+	Arena         bool          // Allocate the files in big blocks, faster and smaller for huge scans but deleted files are only freed with their whole block
 }
 
 // WalkFolder will go through a given folder and subfolders and produces file structure
-// with aggregated file sizes
+// with aggregated file sizes. Progress events are sent to progress, which is closed
+// when the scan finishes, unless it is nil
 func WalkFolder(
 	path string,
 	readDir ReadDir,
 	ignoreFunction ShouldIgnoreFolder,
-	progress chan<- int,
-) *File {
-	var wg sync.WaitGroup
-	c := make(chan bool, 2*runtime.NumCPU())
-	root := walkSubFolderConcurrently(path, 0, nil, ignoringReadDir(ignoreFunction, readDir), c, &wg, progress)
-	wg.Wait()
+	progress chan<- Progress,
+	options WalkOptions,
+) *ScanResult {
+	return WalkFolderContext(context.Background(), path, readDir, ignoreFunction, progress, options)
+}
+
+// WalkFolderContext works like WalkFolder but stops as soon as ctx is cancelled.
+// In that case the partial tree is returned and the folders that could not be
+// fully read, and all their ancestors up to the root, are marked as Incomplete
+func WalkFolderContext(
+	ctx context.Context,
+	path string,
+	readDir ReadDir,
+	ignoreFunction ShouldIgnoreFolder,
+	progress chan<- Progress,
+	options WalkOptions,
+) *ScanResult {
+	return walk(ctx, osSource{readDirFunc: readDir}, path, ignoreFunction, progress, options)
+}
+
+// WalkFS works like WalkFolderContext but reads the folders from fsys instead of the
+// OS filesystem, so zip files, embedded files or virtual filesystems can be scanned.
+// The root and the paths in the tree are slash separated and relative to fsys
+func WalkFS(
+	ctx context.Context,
+	fsys fs.FS,
+	root string,
+	ignoreFunction ShouldIgnoreFolder,
+	progress chan<- Progress,
+	options WalkOptions,
+) *ScanResult {
+	result := walk(ctx, fsSource{fsys: fsys}, root, ignoreFunction, progress, options)
+	result.Root.SlashSeparated = true
+	return result
+}
+
+func walk(
+	ctx context.Context,
+	src source,
+	path string,
+	ignoreFunction ShouldIgnoreFolder,
+	progress chan<- Progress,
+	options WalkOptions,
+) *ScanResult {
+	w := &walker{
+		ctx:          ctx,
+		source:       src,
+		shouldIgnore: ignoreFunction,
+		c:            make(chan bool, 2*runtime.NumCPU()),
+		progress:     progress,
+		options:      options,
+		names:        newNameInterner(),
+	}
+	if options.Arena {
+		w.arena = &arena{}
+	}
+	var ancestors []inodeKey
+	rootInfo, err := w.source.stat(path)
+	if err == nil {
+		ancestors = append(ancestors, fileInodeKey(rootInfo))
+		if options.OneFileSystem {
+			w.rootDevice, _, _ = inodeInfo(rootInfo)
+		}
+	} else {
+		rootInfo = nil
+	}
+	done, finished := make(chan struct{}), make(chan struct{})
+	if progress != nil {
+		go w.reportProgress(done, finished)
+	} else {
+		close(finished)
+	}
+	root := w.walkSubFolderConcurrently(path, 0, nil, rootInfo, ancestors)
+	w.wg.Wait()
+	close(done)
+	<-finished
 
 	root.UpdateSize(-1)
-	close(progress)
-	return root
+	if ctx.Err() != nil {
+		root.Incomplete = true
+	}
+	if progress != nil {
+		close(progress)
+	}
+
+	sort.Slice(w.errors, func(i, j int) bool {
+		return w.errors[i].Path < w.errors[j].Path
+	})
+	return &ScanResult{Root: root, Errors: w.errors}
 }
 
-func walkSubFolderConcurrently(
+// walker holds the state shared by all the goroutines of a scan
+type walker struct {
+	ctx          context.Context
+	source       source
+	shouldIgnore ShouldIgnoreFolder
+	c            chan bool // Limits the number of folders read at the same time
+	wg           sync.WaitGroup
+	progress     chan<- Progress
+	counters     progressCounters
+	options      WalkOptions
+
+	rootDevice uint64 // Device of the scanned folder, 0 if unknown
+
+	names *nameInterner // Shares the memory of repeated names
+	arena *arena        // Allocates the files when WalkOptions.Arena is set, nil otherwise
+
+	errMutex sync.Mutex
+	errors   []*ScanError
+}
+
+// readDir lists the content of a folder, ignored folders are listed as empty
+func (w *walker) readDir(path string) ([]os.FileInfo, error) {
+	if w.shouldIgnore(path) {
+		return []os.FileInfo{}, nil
+	}
+	w.counters.currentPath.Store(path)
+	entries, err := w.source.readDir(path)
+	if err == nil {
+		atomic.AddInt64(&w.counters.dirsScanned, 1)
+	}
+	return entries, err
+}
+
+func (w *walker) addError(scanError *ScanError) {
+	w.errMutex.Lock()
+	w.errors = append(w.errors, scanError)
+	w.errMutex.Unlock()
+	atomic.AddInt64(&w.counters.errors, 1)
+}
+
+// crossesDevice reports whether the folder is in a different filesystem than
+// the scanned one and should not be read
+func (w *walker) crossesDevice(folder os.FileInfo) bool {
+	if !w.options.OneFileSystem || w.rootDevice == 0 {
+		return false
+	}
+	device, _, _ := inodeInfo(folder)
+	return device != 0 && device != w.rootDevice
+}
+
+func (w *walker) walkSubFolderConcurrently(
 	path string,
 	level int,
 	parent *File,
-	readDir ReadDir,
-	c chan bool,
-	wg *sync.WaitGroup,
-	progress chan<- int,
+	info os.FileInfo, // Describes the folder, nil if unknown
+	ancestors []inodeKey, // Folders from the root to this one, to detect symbolic link cycles
 ) *File {
-	result := &File{}
-	entries, err := readDir(path)
+	result := w.arena.newFile()
+	*result = File{
+		Level:  level,
+		IsDir:  true,
+		Parent: parent,
+	}
+	if info != nil {
+		setMetadata(result, info)
+		result.Device, result.Inode, _ = inodeInfo(info)
+	}
+	dirName, name := w.source.split(path)
+	if parent != nil {
+		result.Name = w.names.intern(name)
+	} else {
+		// Root dir
+		// TODO unit test this Join
+		result.Name = w.source.join(dirName, name)
+	}
+
+	entries, err := w.readDir(path)
 	if err != nil {
-		log.Println(err)
-		return nil
+		// Keep the folder in the tree so it is visible that it was skipped
+		w.addError(newScanError(path, err))
+		result.Unreadable = true
+		result.Files = []*File{}
+		return result
 	}
-	dirName, name := filepath.Split(path)
 	result.Files = make([]*File, 0, len(entries))
-	numSubFolders := 0
-	defer updateProgress(progress, &numSubFolders)
 	var mutex sync.Mutex
 	for _, entry := range entries {
-		if entry.IsDir() {
-			numSubFolders++
-			subFolderPath := filepath.Join(path, entry.Name())
-			wg.Add(1)
+		if w.ctx.Err() != nil {
+			// Scan cancelled, the remaining entries are not added
+			mutex.Lock()
+			result.Incomplete = true
+			mutex.Unlock()
+			break
+		}
+		entryPath := w.source.join(path, entry.Name())
+		info := entry // Describes what is added to the tree, the link target if it is followed
+		linkType, linkTarget := NotLink, ""
+		if isSymlink(entry) {
+			if w.options.Symlinks == SkipSymlinks {
+				continue
+			}
+			linkType = Symlink
+			linkTarget, _ = w.source.readLink(entryPath)
+			targetInfo, err := w.source.stat(entryPath)
+			if err != nil {
+				linkType = BrokenSymlink
+				w.addError(&ScanError{Path: entryPath, Kind: BrokenLink, Err: err})
+			} else if w.options.Symlinks == FollowSymlinks && !(targetInfo.IsDir() && isCycle(targetInfo, ancestors)) {
+				info = targetInfo
+			}
+		}
+
+		if info.IsDir() && w.crossesDevice(info) {
+			// Keep the mount point as an empty folder, like ignored folders
+			mountPoint := w.arena.newFile()
+			*mountPoint = File{
+				Name:       w.names.intern(entry.Name()),
+				IsDir:      true,
+				Level:      level + 1,
+				Files:      []*File{},
+				Parent:     result,
+				LinkType:   linkType,
+				LinkTarget: linkTarget,
+			}
+			setMetadata(mountPoint, info)
+			mutex.Lock()
+			result.Files = append(result.Files, mountPoint)
+			mutex.Unlock()
+		} else if info.IsDir() {
+			// Force a copy so sibling folders do not share the same array
+			subFolderAncestors := append(ancestors[:len(ancestors):len(ancestors)], fileInodeKey(info))
+			w.wg.Add(1)
 			go func() {
-				c <- true
-				subFolder := walkSubFolderConcurrently(subFolderPath, level+1, result, readDir, c, wg, progress)
-				if subFolder != nil { // Do not include folders that returned error
+				defer w.wg.Done()
+				select {
+				case w.c <- true:
+				case <-w.ctx.Done():
+					// Scan cancelled while waiting for a free slot
 					mutex.Lock()
-					result.Files = append(result.Files, subFolder)
+					result.Incomplete = true
 					mutex.Unlock()
+					return
 				}
-				<-c
-				wg.Done()
+				subFolder := w.walkSubFolderConcurrently(entryPath, level+1, result, info, subFolderAncestors)
+				subFolder.LinkType, subFolder.LinkTarget = linkType, linkTarget
+				mutex.Lock()
+				result.Files = append(result.Files, subFolder)
+				mutex.Unlock()
+				<-w.c
 			}()
 		} else {
-			size := entry.Size()
-			file := &File{
-				Name:        entry.Name(),
-				FullPath:    filepath.Join(path, entry.Name()),
+			size := info.Size()
+			file := w.arena.newFile()
+			*file = File{
+				Name:        w.names.intern(entry.Name()),
 				Size:        size,
+				Usage:       diskUsage(info),
 				IsDir:       false,
 				Level:       level,
 				NumChildren: 0,
-				Files:       []*File{},
+				Parent:      result,
+				LinkType:    linkType,
+				LinkTarget:  linkTarget,
 			}
+			file.Device, file.Inode, file.NumLinks = inodeInfo(info)
+			setMetadata(file, info)
+			atomic.AddInt64(&w.counters.filesCounted, 1)
+			atomic.AddInt64(&w.counters.bytes, size)
+			atomic.AddInt64(&w.counters.usage, file.Usage)
 			mutex.Lock()
 			result.Files = append(result.Files, file)
 			mutex.Unlock()
 		}
 	}
 
-	result.FullPath = path
-	result.Level = level
-	result.IsDir = true
-	if parent != nil {
-		result.Name = name
-	} else {
-		// Root dir
-		// TODO unit test this Join
-		result.Name = filepath.Join(dirName, name)
-	}
-
 	return result
 }
-
-func updateProgress(progress chan<- int, count *int) {
-	if *count > 0 {
-		progress <- *count
-	}
-}
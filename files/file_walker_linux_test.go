@@ -0,0 +1,49 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// otherDeviceInfo pretends a folder is the mount point of another filesystem
+type otherDeviceInfo struct {
+	os.FileInfo
+}
+
+func (f otherDeviceInfo) Sys() interface{} {
+	st := *f.FileInfo.Sys().(*syscall.Stat_t)
+	st.Dev++
+	return &st
+}
+
+func TestWalkFolderOneFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "mnt", "data"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mnt", "data", "file"), make([]byte, 1000), 0o644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), make([]byte, 10), 0o644))
+
+	readDir := func(path string) ([]os.FileInfo, error) {
+		entries, err := ioutil.ReadDir(path)
+		for i, entry := range entries {
+			if entry.Name() == "mnt" {
+				entries[i] = otherDeviceInfo{entry}
+			}
+		}
+		return entries, err
+	}
+	noIgnore := func(string) bool { return false }
+
+	result := WalkFolder(dir, readDir, noIgnore, make(chan Progress, 10), WalkOptions{})
+	assert.Equal(t, int64(1010), result.Root.Size)
+
+	result = WalkFolder(dir, readDir, noIgnore, make(chan Progress, 10), WalkOptions{OneFileSystem: true})
+	assert.Equal(t, int64(10), result.Root.Size)
+	mnt := FindTestFile(result.Root, "mnt")
+	assert.True(t, mnt.IsDir)
+	assert.Empty(t, mnt.Files, "folders of other filesystems should not be read")
+}
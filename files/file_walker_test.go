@@ -1,10 +1,13 @@
 package files
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -21,7 +24,7 @@ type fakeFile struct {
 func (f fakeFile) Name() string       { return f.fileName }
 func (f fakeFile) Size() int64        { return f.fileSize }
 func (f fakeFile) Mode() os.FileMode  { return 0 }
-func (f fakeFile) ModTime() time.Time { return time.Now() }
+func (f fakeFile) ModTime() time.Time { return time.Time{} }
 func (f fakeFile) IsDir() bool        { return len(f.fakeFiles) > 0 }
 func (f fakeFile) Sys() interface{}   { return nil }
 
@@ -67,28 +70,38 @@ func TestWalkFolderOnSimpleDir(t *testing.T) {
 		}},
 	}}
 	dummyIgnoreFunction := func(p string) bool { return p == filepath.Join("b", "d", "g") }
-	progress := make(chan int, 3)
-	result := WalkFolder("b", createReadDir(testStructure), dummyIgnoreFunction, progress)
+	progress := make(chan Progress, 10)
+	result := WalkFolder("b", createReadDir(testStructure), dummyIgnoreFunction, progress, WalkOptions{})
 	buildExpected := func() *File {
-		b := &File{"b", 180, true, []*File{}, "", 0, 0}
-		c := &File{"c", 100, false, []*File{}, "", 0, 0}
-		d := &File{"d", 80, true, []*File{}, "", 0, 0}
+		b := &File{Name: "b", Size: 180, Usage: 180, IsDir: true, Level: -1, NumChildren: 3}
+		c := &File{Name: "c", Size: 100, Usage: 100}
+		d := &File{Name: "d", Size: 80, Usage: 80, IsDir: true, NumChildren: 2}
 		b.Files = []*File{c, d}
 
-		e := &File{"e", 50, false, []*File{}, "", 0, 0}
-		f := &File{"f", 30, false, []*File{}, "", 0, 0}
-		g := &File{"g", 0, true, []*File{}, "", 0, 0}
+		e := &File{Name: "e", Size: 50, Usage: 50, Level: 1}
+		f := &File{Name: "f", Size: 30, Usage: 30, Level: 1}
+		g := &File{Name: "g", IsDir: true, Files: []*File{}, Level: 1}
 		d.Files = []*File{e, f, g}
+		c.Parent, d.Parent = b, b
+		e.Parent, f.Parent, g.Parent = d, d, d
 
 		return b
 	}
 	expected := buildExpected()
-	assert.Equal(t, expected, result)
-	resultProgress := 0
-	resultProgress += <-progress
-	resultProgress += <-progress
+	assert.Equal(t, expected, result.Root)
+	assert.Empty(t, result.Errors)
+	var resultProgress Progress
+	for event := range progress {
+		resultProgress = event
+	}
+	assert.Equal(t, Progress{
+		DirsScanned:  2,
+		FilesCounted: 3,
+		Bytes:        180,
+		Usage:        180,
+		CurrentPath:  filepath.Join("b", "d"),
+	}, resultProgress, "the last progress event should describe the whole scan")
 	_, more := <-progress
-	assert.Equal(t, 2, resultProgress)
 	assert.False(t, more, "the progress channel should be closed")
 }
 
@@ -96,7 +109,97 @@ func TestWalkFolderHandlesError(t *testing.T) {
 	failing := func(path string) ([]os.FileInfo, error) {
 		return []os.FileInfo{}, errors.New("Not found")
 	}
-	progress := make(chan int, 2)
-	result := WalkFolder("xyz", failing, func(string) bool { return false }, progress)
-	assert.Equal(t, File{}, *result, "WalkFolder didn't return empty file on ReadDir failure")
+	progress := make(chan Progress, 10)
+	result := WalkFolder("xyz", failing, func(string) bool { return false }, progress, WalkOptions{})
+	expected := &File{Name: "xyz", IsDir: true, Files: []*File{}, Level: -1, Unreadable: true}
+	assert.Equal(t, expected, result.Root, "WalkFolder didn't return unreadable root on ReadDir failure")
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, IOError, result.Errors[0].Kind)
+}
+
+func TestWalkFolderCollectsErrors(t *testing.T) {
+	testStructure := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+			}},
+			{"f", 0, []fakeFile{
+				{"g", 50, []fakeFile{}},
+			}},
+		}},
+	}}
+	fakeReadDir := createReadDir(testStructure)
+	readDir := func(path string) ([]os.FileInfo, error) {
+		switch path {
+		case filepath.Join("b", "d"):
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrPermission}
+		case filepath.Join("b", "f"):
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+		return fakeReadDir(path)
+	}
+	progress := make(chan Progress, 10)
+	result := WalkFolder("b", readDir, func(string) bool { return false }, progress, WalkOptions{})
+
+	assert.Equal(t, int64(100), result.Root.Size)
+	assert.Equal(t, int64(100), result.Root.Usage)
+	d := FindTestFile(result.Root, "d")
+	assert.True(t, d.Unreadable, "a folder that cannot be read should be kept and flagged")
+	assert.Len(t, result.Errors, 2)
+	assert.Equal(t, PermissionDenied, result.ErrorFor(filepath.Join("b", "d")).Kind)
+	assert.Equal(t, Vanished, result.ErrorFor(filepath.Join("b", "f")).Kind)
+	assert.Nil(t, result.ErrorFor(filepath.Join("b", "c")))
+}
+
+func TestWalkFolderContextCancelled(t *testing.T) {
+	testStructure := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+			}},
+		}},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	progress := make(chan Progress, 10)
+	result := WalkFolderContext(ctx, "b", createReadDir(testStructure), func(string) bool { return false }, progress, WalkOptions{})
+	assert.True(t, result.Root.Incomplete, "a cancelled scan should be marked as incomplete")
+	assert.Empty(t, result.Root.Files, "a scan cancelled before starting should not contain files")
+	for range progress {
+		// Drain the final progress event, the loop ends when the channel is closed
+	}
+}
+
+func TestWalkFolderDiskUsage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("block counts are only checked on linux")
+	}
+	dir := t.TempDir()
+	sparse, err := os.Create(filepath.Join(dir, "sparse"))
+	assert.NoError(t, err)
+	assert.NoError(t, sparse.Truncate(10*MEGABYTE))
+	assert.NoError(t, sparse.Close())
+
+	progress := make(chan Progress, 10)
+	result := WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, progress, WalkOptions{})
+	file := FindTestFile(result.Root, "sparse")
+	assert.Equal(t, int64(10*MEGABYTE), file.Size)
+	assert.Less(t, file.Usage, file.Size, "a sparse file should not allocate all its apparent size")
+	assert.Equal(t, file.Usage, result.Root.Usage)
+	assert.Equal(t, file.Usage, result.Root.SizeBy(DiskUsage))
+}
+
+func TestWalkFolderProgressErrors(t *testing.T) {
+	failing := func(path string) ([]os.FileInfo, error) {
+		return []os.FileInfo{}, errors.New("Not found")
+	}
+	progress := make(chan Progress, 10)
+	WalkFolder("xyz", failing, func(string) bool { return false }, progress, WalkOptions{})
+	var resultProgress Progress
+	for event := range progress {
+		resultProgress = event
+	}
+	assert.Equal(t, Progress{CurrentPath: "xyz", Errors: 1}, resultProgress)
 }
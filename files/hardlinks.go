@@ -0,0 +1,73 @@
+package files
+
+// inodeKey identifies a file in the filesystem whatever the path used to reach it
+type inodeKey struct {
+	device uint64
+	inode  uint64
+}
+
+func (f *File) inodeKey() inodeKey {
+	return inodeKey{device: f.Device, inode: f.Inode}
+}
+
+// IsHardLinked reports whether the file content is also reachable through other hard links
+func (f *File) IsHardLinked() bool {
+	return !f.IsDir && f.NumLinks > 1
+}
+
+// hasSharedContent reports whether the file is, or the folder contains, a hard linked
+// file or a followed link to a folder, whose size may be counted somewhere else
+func (f *File) hasSharedContent() bool {
+	if f.IsHardLinked() || (f.IsDir && f.LinkType == Symlink) {
+		return true
+	}
+	for _, child := range f.Files {
+		if child.hasSharedContent() {
+			return true
+		}
+	}
+	return false
+}
+
+// ReclaimableSize returns the space that deleting the selected files will really free.
+// A hard linked file only frees space when all its links are deleted, so it is only
+// counted if every link is part of the selection
+func ReclaimableSize(selected []*File, metric SizeMetric) int64 {
+	var size int64
+	visited := map[*File]struct{}{}
+	links := map[inodeKey]int{}
+	linked := map[inodeKey]*File{}
+
+	var visit func(file *File)
+	visit = func(file *File) {
+		if _, ok := visited[file]; ok {
+			// Selected twice, e.g. a file and the folder containing it
+			return
+		}
+		visited[file] = struct{}{}
+		if file.IsDir {
+			for _, child := range file.Files {
+				visit(child)
+			}
+			return
+		}
+		if file.IsHardLinked() {
+			key := file.inodeKey()
+			links[key]++
+			linked[key] = file
+			return
+		}
+		size += file.SizeBy(metric)
+	}
+	for _, file := range selected {
+		visit(file)
+	}
+
+	for key, count := range links {
+		file := linked[key]
+		if uint64(count) >= file.NumLinks {
+			size += file.SizeBy(metric)
+		}
+	}
+	return size
+}
@@ -0,0 +1,130 @@
+package files
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHardLink(name string, size int64, inode uint64, links uint64) *File {
+	file := NewTestFile(name, size)
+	file.Device, file.Inode, file.NumLinks = 1, inode, links
+	return file
+}
+
+func TestUpdateSizeCountsHardLinksOnce(t *testing.T) {
+	folder := NewTestFolder("a",
+		NewTestFolder("b",
+			newTestHardLink("c", 100, 7, 2),
+			NewTestFile("d", 10),
+		),
+		NewTestFolder("e",
+			newTestHardLink("f", 100, 7, 2),
+		),
+	)
+	assert.Equal(t, int64(110), folder.Size)
+	assert.Equal(t, int64(3), folder.NumChildren)
+
+	// Whatever the order the folders were read in, the first link by name gets the bytes
+	reversed := NewTestFolder("a",
+		NewTestFolder("e",
+			newTestHardLink("f", 100, 7, 2),
+		),
+		NewTestFolder("b",
+			newTestHardLink("c", 100, 7, 2),
+		),
+	)
+	assert.Equal(t, int64(100), FindTestFile(reversed, "b").Size)
+	assert.Equal(t, int64(0), FindTestFile(reversed, "e").Size)
+}
+
+func TestReclaimableSize(t *testing.T) {
+	c := newTestHardLink("c", 100, 7, 2)
+	f := newTestHardLink("f", 100, 7, 2)
+	g := newTestHardLink("g", 50, 8, 3)
+	b := NewTestFolder("b", c, NewTestFile("d", 10), g)
+	e := NewTestFolder("e", f)
+	NewTestFolder("a", b, e)
+
+	// Only one of the two links of c is deleted, g has links outside the scan
+	assert.Equal(t, int64(10), ReclaimableSize([]*File{b}, ApparentSize))
+	// Both links of c are deleted
+	assert.Equal(t, int64(110), ReclaimableSize([]*File{b, e}, ApparentSize))
+	// Selecting a file and its folder does not count it twice
+	assert.Equal(t, int64(110), ReclaimableSize([]*File{b, e, f}, ApparentSize))
+}
+
+func TestWalkFolderHardLinks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("hard links are only checked on linux")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "a"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "b"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "file"), make([]byte, 1000), 0o644))
+	assert.NoError(t, os.Link(filepath.Join(dir, "a", "file"), filepath.Join(dir, "b", "link")))
+
+	progress := make(chan Progress, 10)
+	result := WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, progress, WalkOptions{})
+	link := FindTestFile(result.Root, "link")
+	assert.True(t, link.IsHardLinked())
+	assert.Equal(t, int64(1000), result.Root.Size)
+	assert.Equal(t, int64(2), result.Root.NumChildren)
+	assert.Equal(t, int64(0), ReclaimableSize([]*File{link}, ApparentSize))
+	assert.Equal(t, int64(1000), ReclaimableSize(result.Root.Files, ApparentSize))
+}
+
+func TestRemoveHardLink(t *testing.T) {
+	newTree := func() *File {
+		return NewTestFolder("root",
+			NewTestFolder("a",
+				newTestHardLink("x", 100, 7, 2),
+			),
+			NewTestFolder("e",
+				newTestHardLink("y", 100, 7, 2),
+				NewTestFile("z", 10),
+			),
+		)
+	}
+
+	// The link that was not counted frees nothing
+	root := newTree()
+	assert.NoError(t, root.Remove(FindTestFile(root, "y")))
+	assert.Equal(t, int64(100), FindTestFile(root, "a").Size)
+	assert.Equal(t, int64(10), FindTestFile(root, "e").Size)
+	assert.Equal(t, int64(110), root.Size)
+	assert.Equal(t, int64(2), root.NumChildren)
+
+	// The content is still reachable through the other link, which counts it now
+	root = newTree()
+	assert.NoError(t, root.Remove(FindTestFile(root, "a")))
+	assert.Equal(t, int64(110), FindTestFile(root, "e").Size)
+	assert.Equal(t, int64(110), root.Size)
+	assert.Equal(t, int64(2), root.NumChildren)
+	assert.Equal(t, 0, root.Level, "the level of the root should be kept")
+}
+
+func TestRescanHardLinks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("hard links are only checked on linux")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "a"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "b"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "file"), make([]byte, 1000), 0o644))
+	assert.NoError(t, os.Link(filepath.Join(dir, "a", "file"), filepath.Join(dir, "b", "link")))
+	noIgnore := func(string) bool { return false }
+	root := WalkFolder(dir, ioutil.ReadDir, noIgnore, nil, WalkOptions{}).Root
+
+	b := FindTestFile(root, "b")
+	_, err := root.Rescan(context.Background(), b, ioutil.ReadDir, noIgnore, nil, WalkOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), root.Size, "the link scanned again should not be counted twice")
+	assert.Equal(t, int64(0), b.Size)
+	assert.Equal(t, int64(1000), FindTestFile(root, "a").Size)
+}
@@ -0,0 +1,62 @@
+package files
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Largest returns the n biggest files and folders inside root, of at least minSize,
+// biggest first. Folders are listed along with what they contain, like du does
+func Largest(root *File, n int, minSize int64, metric SizeMetric) []*File {
+	if n <= 0 {
+		return nil
+	}
+	largest := &fileHeap{metric: metric}
+	var visit func(folder *File)
+	visit = func(folder *File) {
+		for _, file := range folder.Files {
+			size := file.SizeBy(metric)
+			if size < minSize || (largest.Len() == n && size <= largest.min()) {
+				// Nothing inside can be bigger than the file itself
+				continue
+			}
+			heap.Push(largest, file)
+			if largest.Len() > n {
+				heap.Pop(largest)
+			}
+			if file.IsDir {
+				visit(file)
+			}
+		}
+	}
+	visit(root)
+
+	result := largest.files
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SizeBy(metric) != result[j].SizeBy(metric) {
+			return result[i].SizeBy(metric) > result[j].SizeBy(metric)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// fileHeap keeps the smallest file first so it can be replaced by a bigger one
+type fileHeap struct {
+	files  []*File
+	metric SizeMetric
+}
+
+func (h *fileHeap) Len() int { return len(h.files) }
+func (h *fileHeap) Less(i, j int) bool {
+	return h.files[i].SizeBy(h.metric) < h.files[j].SizeBy(h.metric)
+}
+func (h *fileHeap) Swap(i, j int)      { h.files[i], h.files[j] = h.files[j], h.files[i] }
+func (h *fileHeap) Push(x interface{}) { h.files = append(h.files, x.(*File)) }
+func (h *fileHeap) min() int64         { return h.files[0].SizeBy(h.metric) }
+
+func (h *fileHeap) Pop() interface{} {
+	last := h.files[len(h.files)-1]
+	h.files = h.files[:len(h.files)-1]
+	return last
+}
@@ -0,0 +1,38 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLargest(t *testing.T) {
+	folder := NewTestFolder("b",
+		NewTestFile("c", 120),
+		NewTestFolder("d",
+			NewTestFile("e", 50),
+			NewTestFile("f", 30),
+			NewTestFolder("g",
+				NewTestFile("i", 60),
+				NewTestFile("j", 40),
+			),
+		),
+	)
+	names := func(largest []*File) []string {
+		result := []string{}
+		for _, file := range largest {
+			result = append(result, file.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"d", "c", "g", "i"}, names(Largest(folder, 4, 0, ApparentSize)))
+	assert.Equal(t, []string{"d", "c", "g", "i", "e"}, names(Largest(folder, 10, 50, ApparentSize)))
+	assert.Empty(t, Largest(folder, 0, 0, ApparentSize))
+	assert.Empty(t, Largest(folder, 10, 1000, ApparentSize))
+}
+
+func TestLargestTies(t *testing.T) {
+	folder := NewTestFolder("a", NewTestFile("c", 10), NewTestFile("b", 10))
+	largest := Largest(folder, 2, 0, ApparentSize)
+	assert.Equal(t, "b", largest[0].Name, "files of the same size should be sorted by name")
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"os"
+	"time"
+)
+
+// UnixNano is a time in nanoseconds since the Unix epoch, zero when it is unknown.
+// Files store their times this way because a time.Time takes three times the memory
+type UnixNano int64
+
+// NewUnixNano converts t, the zero time is kept as unknown
+func NewUnixNano(t time.Time) UnixNano {
+	if t.IsZero() {
+		return 0
+	}
+	return UnixNano(t.UnixNano())
+}
+
+// Time converts the time back, unknown times are the zero time
+func (n UnixNano) Time() time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(n))
+}
+
+// IsZero reports whether the time is unknown
+func (n UnixNano) IsZero() bool {
+	return n == 0
+}
+
+// After reports whether the time is later than other
+func (n UnixNano) After(other UnixNano) bool {
+	return n > other
+}
+
+// setMetadata copies to the file the times, owner and permissions found in info
+func setMetadata(file *File, info os.FileInfo) {
+	file.ModTime = NewUnixNano(info.ModTime())
+	file.AccessTime = NewUnixNano(accessTime(info))
+	file.Uid, file.Gid = ownerInfo(info)
+	file.Mode = info.Mode()
+}
+
+// updateNewest makes the newest times of the folder include the ones of child
+func (f *File) updateNewest(child *File) {
+	if child.NewestModTime.After(f.NewestModTime) {
+		f.NewestModTime = child.NewestModTime
+	}
+	if child.NewestAccessTime.After(f.NewestAccessTime) {
+		f.NewestAccessTime = child.NewestAccessTime
+	}
+}
+
+// Stat describes the file at path without scanning it, for files given directly
+// instead of found inside a scanned folder. Symbolic links are not followed
+func Stat(path string) (*File, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &File{Name: path, Size: info.Size(), Usage: diskUsage(info), IsDir: info.IsDir(), Level: -1}
+	file.Device, file.Inode, file.NumLinks = inodeInfo(info)
+	setMetadata(file, info)
+	if file.IsDir {
+		file.Files = []*File{}
+	}
+	file.UpdateSize(-1)
+	return file, nil
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSizeNewestTimes(t *testing.T) {
+	old := NewUnixNano(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	recent := NewUnixNano(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewTestFile("c", 10)
+	c.ModTime, c.AccessTime = old, recent
+	d := NewTestFile("d", 10)
+	d.ModTime, d.AccessTime = recent, old
+	folder := NewTestFolder("a", NewTestFolder("b", c), d)
+
+	assert.Equal(t, recent, folder.NewestModTime)
+	assert.Equal(t, recent, folder.NewestAccessTime)
+	b := FindTestFile(folder, "b")
+	assert.Equal(t, old, b.NewestModTime)
+	assert.Equal(t, recent, b.NewestAccessTime)
+}
+
+func TestWalkFolderMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("owners and permission bits are only checked on unix")
+	}
+	dir := t.TempDir()
+	mtime := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	atime := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o750))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "file"), make([]byte, 10), 0o640))
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "sub", "file"), 0o640))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "sub", "file"), atime, mtime))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "sub"), mtime, mtime.Add(-time.Hour)))
+
+	result := WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, make(chan Progress, 10), WalkOptions{})
+	file := FindTestFile(result.Root, "file")
+	assert.Equal(t, NewUnixNano(mtime), file.ModTime)
+	assert.Equal(t, NewUnixNano(atime), file.AccessTime)
+	assert.Equal(t, os.FileMode(0o640), file.Mode.Perm())
+	assert.Equal(t, uint32(os.Getuid()), file.Uid)
+	assert.Equal(t, uint32(os.Getgid()), file.Gid)
+
+	sub := FindTestFile(result.Root, "sub")
+	assert.True(t, sub.Mode.IsDir())
+	assert.Equal(t, NewUnixNano(mtime.Add(-time.Hour)), sub.ModTime)
+	assert.Equal(t, NewUnixNano(mtime), sub.NewestModTime, "folders should know the newest modification inside them")
+	assert.False(t, sub.NewestAccessTime.Time().Before(atime))
+}
+
+func TestStat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c")
+	assert.NoError(t, ioutil.WriteFile(path, make([]byte, 100), 0644))
+	file, err := Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, path, file.Name)
+	assert.Equal(t, path, file.Path())
+	assert.Equal(t, int64(100), file.Size)
+	assert.False(t, file.IsDir)
+	assert.Equal(t, file.ModTime, file.NewestModTime)
+
+	_, err = Stat(filepath.Join(t.TempDir(), "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
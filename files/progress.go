@@ -0,0 +1,65 @@
+package files
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProgressInterval is how often the walker reports the progress of a scan
+const ProgressInterval = 100 * time.Millisecond
+
+// Progress is a snapshot of how far a scan has gone
+type Progress struct {
+	DirsScanned  int64  // Folders read so far
+	FilesCounted int64  // Files found so far
+	Bytes        int64  // Apparent size of the files found so far
+	Usage        int64  // Disk usage of the files found so far
+	CurrentPath  string // Last folder the walker started reading
+	Errors       int64  // Problems found so far
+}
+
+// progressCounters accumulates the progress of the goroutines of a scan
+type progressCounters struct {
+	dirsScanned  int64 // Only accessed through sync/atomic
+	filesCounted int64
+	bytes        int64
+	usage        int64
+	errors       int64
+	currentPath  atomic.Value // string
+}
+
+func (p *progressCounters) snapshot() Progress {
+	currentPath, _ := p.currentPath.Load().(string)
+	return Progress{
+		DirsScanned:  atomic.LoadInt64(&p.dirsScanned),
+		FilesCounted: atomic.LoadInt64(&p.filesCounted),
+		Bytes:        atomic.LoadInt64(&p.bytes),
+		Usage:        atomic.LoadInt64(&p.usage),
+		CurrentPath:  currentPath,
+		Errors:       atomic.LoadInt64(&p.errors),
+	}
+}
+
+// reportProgress sends a snapshot of the counters every ProgressInterval until done is
+// closed, then it sends the final state of the scan. Snapshots are dropped while
+// the receiver is busy so it never slows down the scan
+func (w *walker) reportProgress(done <-chan struct{}, finished chan<- struct{}) {
+	defer close(finished)
+	ticker := time.NewTicker(ProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case w.progress <- w.counters.snapshot():
+			default:
+			}
+		case <-done:
+			select {
+			case w.progress <- w.counters.snapshot():
+			case <-w.ctx.Done():
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrNotInTree is returned when a file is not part of the tree it is looked for in
+var ErrNotInTree = errors.New("file is not part of the tree")
+
+// ErrNotFolder is returned when a folder is required and a file is given
+var ErrNotFolder = errors.New("file is not a folder")
+
+// Rescan walks again the folder target, which must be f or be inside f, and replaces
+// its content in place with what is found now. The sizes and number of children of
+// the folders containing it are updated with the difference. Progress events are
+// sent to progress unless it is nil
+func (f *File) Rescan(
+	ctx context.Context,
+	target *File,
+	readDir ReadDir,
+	ignoreFunction ShouldIgnoreFolder,
+	progress chan<- Progress,
+	options WalkOptions,
+) (*ScanResult, error) {
+	if !target.IsDir {
+		return nil, ErrNotFolder
+	}
+	ancestors, found := f.pathTo(target)
+	if !found {
+		return nil, ErrNotInTree
+	}
+
+	result := WalkFolderContext(ctx, target.Path(), readDir, ignoreFunction, progress, options)
+	f.splice(ancestors, target, result.Root)
+	result.Root = target
+	return result, nil
+}
+
+// splice replaces the content of target with the one of the new scan and propagates
+// the differences to its ancestors, from the root to the parent of target
+func (f *File) splice(ancestors []*File, target *File, rescanned *File) {
+	// Which link counts the shared content depends on the rest of the tree
+	shared := target.hasSharedContent() || rescanned.hasSharedContent()
+	sizeDelta := rescanned.Size - target.Size
+	usageDelta := rescanned.Usage - target.Usage
+	childrenDelta := rescanned.NumChildren - target.NumChildren
+
+	// Keep what depends on the position of the folder in the tree
+	name, level, linkType, linkTarget, parent := target.Name, target.Level, target.LinkType, target.LinkTarget, target.Parent
+	*target = *rescanned
+	target.Name, target.LinkType, target.LinkTarget = name, linkType, linkTarget
+	target.Parent = parent
+	target.setLevel(level)
+	for _, child := range target.Files {
+		child.Parent = target
+	}
+	if shared {
+		f.UpdateSize(f.Level)
+		return
+	}
+
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		ancestor := ancestors[i]
+		ancestor.Size += sizeDelta
+		ancestor.Usage += usageDelta
+		ancestor.NumChildren += childrenDelta
+		ancestor.updateFromChildren()
+	}
+}
+
+// updateFromChildren updates what a folder summarises from its direct children,
+// other than sizes, and sorts them again
+func (f *File) updateFromChildren() {
+	f.NewestModTime, f.NewestAccessTime = f.ModTime, f.AccessTime
+	f.Incomplete = false
+	for _, child := range f.Files {
+		f.updateNewest(child)
+		if child.Incomplete {
+			f.Incomplete = true
+		}
+	}
+	sort.Slice(f.Files, func(i, j int) bool {
+		return f.Files[i].Size > f.Files[j].Size
+	})
+}
+
+// setLevel sets the level of the folder and of everything inside it
+func (f *File) setLevel(level int) {
+	f.Level = level
+	for _, child := range f.Files {
+		if child.IsDir {
+			child.setLevel(level + 1)
+		} else {
+			child.Level = level + 1
+		}
+	}
+}
+
+// pathTo returns the folders from f down to the parent of target
+func (f *File) pathTo(target *File) ([]*File, bool) {
+	var path []*File
+	for file := target; file != f; file = file.Parent {
+		if file.Parent == nil {
+			// Reached the root of another tree
+			return nil, false
+		}
+		path = append(path, file.Parent)
+	}
+	// Parents were found from target up to f
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
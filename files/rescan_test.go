@@ -0,0 +1,67 @@
+package files
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRescanSubtree(t *testing.T) {
+	before := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+				{"f", 30, []fakeFile{}},
+			}},
+		}},
+	}}
+	after := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+				{"g", 0, []fakeFile{
+					{"h", 200, []fakeFile{}},
+					{"i", 20, []fakeFile{}},
+				}},
+			}},
+		}},
+	}}
+	noIgnore := func(string) bool { return false }
+	root := WalkFolder("b", createReadDir(before), noIgnore, nil, WalkOptions{}).Root
+	d := FindTestFile(root, "d")
+	assert.Equal(t, "c", root.Files[0].Name)
+
+	result, err := root.Rescan(context.Background(), d, createReadDir(after), noIgnore, nil, WalkOptions{})
+	assert.NoError(t, err)
+	assert.Same(t, d, result.Root, "the folder should be updated in place")
+	assert.Same(t, root, d.Parent)
+	assert.Same(t, d, FindTestFile(root, "g").Parent, "the new content should point to the folder in the tree")
+	assert.Same(t, d, FindTestFile(root, "d"))
+	assert.Equal(t, "d", d.Name)
+	assert.Equal(t, 0, d.Level)
+	assert.Equal(t, int64(270), d.Size)
+	assert.Equal(t, int64(3), d.NumChildren)
+	assert.Equal(t, int64(370), root.Size)
+	assert.Equal(t, int64(370), root.Usage)
+	assert.Equal(t, int64(4), root.NumChildren)
+	assert.Equal(t, "d", root.Files[0].Name, "ancestors should be sorted again")
+
+	g := FindTestFile(root, "g")
+	assert.Equal(t, 1, g.Level)
+	assert.Equal(t, filepath.Join("b", "d", "g"), g.Path())
+	assert.Equal(t, 2, FindTestFile(root, "h").Level)
+	assert.Nil(t, FindTestFile(root, "f"))
+}
+
+func TestRescanErrors(t *testing.T) {
+	root := NewTestFolder("a", NewTestFolder("b"), NewTestFile("c", 10))
+	noIgnore := func(string) bool { return false }
+	_, err := root.Rescan(context.Background(), FindTestFile(root, "c"), nil, noIgnore, nil, WalkOptions{})
+	assert.ErrorIs(t, err, ErrNotFolder)
+	_, err = root.Rescan(context.Background(), NewTestFolder("x"), nil, noIgnore, nil, WalkOptions{})
+	assert.ErrorIs(t, err, ErrNotInTree)
+}
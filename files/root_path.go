@@ -0,0 +1,47 @@
+package files
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// RootPath returns the path scanned to analyze the whole disk, which is the root of
+// the filesystem or the drive where the OS is installed
+func RootPath() string {
+	switch runtime.GOOS {
+	case "windows":
+		return windowsRootPath()
+	case "darwin":
+		// macOS
+		return "/"
+	case "android", "linux":
+		// For Android apps you will need to request permission for reading from external folders.
+		// I was not able to perform that with gioui or golang, maybe you need to create a connector for JAVA
+		return "/"
+	case "ios":
+		// iOS apps are sandboxed too, so the root path will not be directly accessible
+		return iosRootPath()
+	default:
+		// Other Unix systems like the BSDs
+		return "/"
+	}
+}
+
+func windowsRootPath() string {
+	// On Windows, the root path is typically the drive where the OS is installed,
+	// so we need to get the current drive and concatenate it with the path separator.
+	return filepath.VolumeName(os.Getenv("SystemDrive")) + string(filepath.Separator)
+}
+
+func iosRootPath() string {
+	// In iOS, the application's root path is restricted, but you can use other directories like the Documents directory.
+	// This is just an example of how you could handle it, but it's not the actual root path.
+	documentsDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Println("Wasn't able to retrieve the home folder of the user")
+		return "/"
+	}
+	return filepath.Join(documentsDir, "Documents")
+}
@@ -0,0 +1,21 @@
+package files
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootPath(t *testing.T) {
+	switch runtime.GOOS {
+	case "windows":
+		t.Setenv("SystemDrive", "D:")
+		assert.Equal(t, `D:\`, RootPath())
+	case "ios":
+		assert.Equal(t, "Documents", filepath.Base(RootPath()))
+	default:
+		assert.Equal(t, "/", RootPath())
+	}
+}
@@ -0,0 +1,74 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// ScanErrorKind classifies why a path could not be read during a scan
+type ScanErrorKind int
+
+const (
+	PermissionDenied ScanErrorKind = iota // Not allowed to read the folder
+	Vanished                              // The folder was removed while scanning
+	IOError                               // Any other error reading the folder
+	BrokenLink                            // Symbolic link whose target does not exist
+)
+
+func (k ScanErrorKind) String() string {
+	switch k {
+	case PermissionDenied:
+		return "permission denied"
+	case Vanished:
+		return "vanished"
+	case BrokenLink:
+		return "broken symbolic link"
+	default:
+		return "I/O error"
+	}
+}
+
+// ScanError describes a path that was skipped because it could not be read,
+// or a symbolic link whose target could not be found
+type ScanError struct {
+	Path string        // Path that could not be read
+	Kind ScanErrorKind // Why it could not be read
+	Err  error         // Original error returned when reading the path
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Kind)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+func newScanError(path string, err error) *ScanError {
+	kind := IOError
+	if errors.Is(err, fs.ErrPermission) {
+		kind = PermissionDenied
+	} else if errors.Is(err, fs.ErrNotExist) {
+		kind = Vanished
+	}
+	return &ScanError{Path: path, Kind: kind, Err: err}
+}
+
+// ScanResult is what a scan produces: the tree of files and the paths that could not be read
+type ScanResult struct {
+	Root   *File        // Root of the scanned tree
+	Errors []*ScanError // Paths that could not be read, sorted by path
+}
+
+// ErrorFor returns the error found when reading path, or nil if it was read correctly
+func (r *ScanResult) ErrorFor(path string) *ScanError {
+	i := sort.Search(len(r.Errors), func(i int) bool {
+		return r.Errors[i].Path >= path
+	})
+	if i < len(r.Errors) && r.Errors[i].Path == path {
+		return r.Errors[i]
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package files
+
+// SizeMetric selects which size of a file is used to show and compare files
+type SizeMetric int
+
+const (
+	ApparentSize SizeMetric = iota // Size reported for the file content
+	DiskUsage                      // Space really allocated on disk
+)
+
+func (m SizeMetric) String() string {
+	if m == DiskUsage {
+		return "Disk Usage"
+	}
+	return "Size"
+}
+
+// SizeBy returns the size of the file measured with the given metric
+func (f *File) SizeBy(metric SizeMetric) int64 {
+	if metric == DiskUsage {
+		return f.Usage
+	}
+	return f.Size
+}
@@ -0,0 +1,61 @@
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// source is where the walker reads folders from
+type source interface {
+	readDir(name string) ([]os.FileInfo, error)
+	stat(name string) (os.FileInfo, error) // Follows symbolic links
+	readLink(name string) (string, error)
+	join(elem ...string) string
+	split(name string) (dir, file string)
+}
+
+// osSource reads the folders of the OS filesystem
+type osSource struct {
+	readDirFunc ReadDir
+}
+
+func (s osSource) readDir(name string) ([]os.FileInfo, error) { return s.readDirFunc(name) }
+func (s osSource) stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+func (s osSource) readLink(name string) (string, error)       { return os.Readlink(name) }
+func (s osSource) join(elem ...string) string                 { return filepath.Join(elem...) }
+func (s osSource) split(name string) (string, string)         { return filepath.Split(name) }
+
+// fsSource reads the folders of an fs.FS, like a zip file, an embed.FS or an fstest.MapFS.
+// Paths are slash separated and relative to the root of the fs.FS
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s fsSource) readDir(name string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the folder was listed
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (s fsSource) stat(name string) (os.FileInfo, error) { return fs.Stat(s.fsys, name) }
+
+func (s fsSource) readLink(name string) (string, error) {
+	// fs.FS has no way of reading the target of a link
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+func (s fsSource) join(elem ...string) string         { return path.Join(elem...) }
+func (s fsSource) split(name string) (string, string) { return path.Split(name) }
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkFSOnMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b/c":     {Data: make([]byte, 100)},
+		"b/d/e":   {Data: make([]byte, 50)},
+		"b/d/f":   {Data: make([]byte, 30)},
+		"b/d/g/h": {Data: make([]byte, 10)},
+	}
+	ignore := func(p string) bool { return p == "b/d/g" }
+	result := WalkFS(context.Background(), fsys, "b", ignore, make(chan Progress, 10), WalkOptions{})
+
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "b", result.Root.Name)
+	assert.Equal(t, int64(180), result.Root.Size)
+	assert.Equal(t, int64(3), result.Root.NumChildren)
+	assert.Equal(t, "b/d/e", FindTestFile(result.Root, "e").Path())
+	assert.True(t, result.Root.SlashSeparated)
+	assert.Same(t, FindTestFile(result.Root, "e"), result.Root.Lookup("b/d/e"), "paths of an fs.FS are slash separated on every OS")
+	assert.Nil(t, result.Root.Lookup("bd/e"))
+	assert.Empty(t, FindTestFile(result.Root, "g").Files, "ignored folders should not be read")
+}
+
+func TestWalkFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":   {Data: make([]byte, 10)},
+		"b/c": {Data: make([]byte, 20)},
+	}
+	result := WalkFS(context.Background(), fsys, ".", func(string) bool { return false }, make(chan Progress, 10), WalkOptions{})
+	assert.Equal(t, ".", result.Root.Name)
+	assert.Equal(t, int64(30), result.Root.Size)
+	assert.Equal(t, "b/c", FindTestFile(result.Root, "c").Path())
+	assert.Same(t, FindTestFile(result.Root, "c"), result.Root.Lookup("b/c"))
+	assert.Nil(t, result.Root.Lookup("../b/c"))
+}
+
+func TestWalkFSMissingRoot(t *testing.T) {
+	result := WalkFS(context.Background(), fstest.MapFS{}, "missing", func(string) bool { return false }, make(chan Progress, 10), WalkOptions{})
+	assert.True(t, result.Root.Unreadable)
+	assert.Equal(t, Vanished, result.ErrorFor("missing").Kind)
+}
+
+func TestWalkFSOnZip(t *testing.T) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, size := range map[string]int{"logs/old.log": 300, "logs/new.log": 100, "readme": 5} {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(make([]byte, size))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, archive.Close())
+
+	fsys, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+	result := WalkFS(context.Background(), fsys, ".", func(string) bool { return false }, make(chan Progress, 10), WalkOptions{})
+	assert.Equal(t, int64(405), result.Root.Size)
+	logs := FindTestFile(result.Root, "logs")
+	assert.Equal(t, int64(400), logs.Size)
+	assert.Equal(t, "old.log", logs.Files[0].Name, "biggest files should go first")
+}
@@ -0,0 +1,17 @@
+//go:build darwin || freebsd || netbsd
+
+package files
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when the file was last read
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec))
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,32 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package files
+
+import (
+	"os"
+	"time"
+)
+
+// diskUsage returns the bytes allocated on disk for the file. The block count
+// is not available on this OS so the apparent size is used instead
+func diskUsage(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// inodeInfo returns the device, inode and number of hard links of the file.
+// They are not available on this OS so files are never treated as hard links
+func inodeInfo(info os.FileInfo) (uint64, uint64, uint64) {
+	return 0, 0, 0
+}
+
+// ownerInfo returns the user and group owning the file.
+// Owners are not numeric on this OS so they are left empty
+func ownerInfo(info os.FileInfo) (uint32, uint32) {
+	return 0, 0
+}
+
+// accessTime returns when the file was last read. It is not available
+// on this OS so the modification time is used instead
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
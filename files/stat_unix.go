@@ -0,0 +1,33 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package files
+
+import (
+	"os"
+	"syscall"
+)
+
+// diskUsage returns the bytes allocated on disk for the file. The stat block
+// count is always expressed in 512 byte units, whatever the filesystem block size
+func diskUsage(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return info.Size()
+}
+
+// inodeInfo returns the device, inode and number of hard links of the file
+func inodeInfo(info os.FileInfo) (uint64, uint64, uint64) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), uint64(st.Ino), uint64(st.Nlink)
+	}
+	return 0, 0, 0
+}
+
+// ownerInfo returns the user and group owning the file
+func ownerInfo(info os.FileInfo) (uint32, uint32) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Uid, st.Gid
+	}
+	return 0, 0
+}
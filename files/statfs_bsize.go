@@ -0,0 +1,11 @@
+//go:build darwin
+
+package files
+
+import "syscall"
+
+// blockSize returns the size of the blocks counted by statfs, which macOS
+// always counts in units of Bsize
+func blockSize(st *syscall.Statfs_t) int64 {
+	return int64(st.Bsize)
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package files
+
+import "syscall"
+
+// blockSize returns the size of the blocks counted by statfs, which is the
+// fragment size. Old kernels leave it unset and count blocks of Bsize
+func blockSize(st *syscall.Statfs_t) int64 {
+	if st.Frsize != 0 {
+		return int64(st.Frsize)
+	}
+	return int64(st.Bsize)
+}
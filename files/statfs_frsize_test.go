@@ -0,0 +1,15 @@
+//go:build linux
+
+package files
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockSize(t *testing.T) {
+	assert.Equal(t, int64(1024), blockSize(&syscall.Statfs_t{Bsize: 4096, Frsize: 1024}), "blocks are counted in fragments")
+	assert.Equal(t, int64(4096), blockSize(&syscall.Statfs_t{Bsize: 4096}))
+}
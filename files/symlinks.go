@@ -0,0 +1,92 @@
+package files
+
+import (
+	"os"
+)
+
+// LinkType tells whether a file is a symbolic link
+type LinkType uint8
+
+const (
+	NotLink       LinkType = iota // Regular file or folder
+	Symlink                       // Symbolic link to an existing file or folder
+	BrokenSymlink                 // Symbolic link whose target does not exist
+)
+
+// SymlinkPolicy decides what the walker does with symbolic links
+type SymlinkPolicy int
+
+const (
+	CountSymlinks  SymlinkPolicy = iota // Add the link itself, with the size of the link
+	SkipSymlinks                        // Leave links out of the tree
+	FollowSymlinks                      // Add the target of the link, folders are walked unless they form a cycle
+)
+
+func (p SymlinkPolicy) String() string {
+	switch p {
+	case SkipSymlinks:
+		return "Skip"
+	case FollowSymlinks:
+		return "Follow"
+	default:
+		return "Count link"
+	}
+}
+
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// isCycle reports whether following a link to folder would walk again one of the
+// folders that contain the link
+func isCycle(folder os.FileInfo, ancestors []inodeKey) bool {
+	key := fileInodeKey(folder)
+	if key == (inodeKey{}) {
+		return false
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == key {
+			return true
+		}
+	}
+	return false
+}
+
+func fileInodeKey(info os.FileInfo) inodeKey {
+	device, inode, _ := inodeInfo(info)
+	return inodeKey{device: device, inode: inode}
+}
+
+// collectFolders adds the folders of the tree that are not reached through a
+// symbolic link, which are counted at their real place instead of through links
+func (f *File) collectFolders(folders map[inodeKey]struct{}) {
+	if !f.IsDir || f.LinkType != NotLink {
+		return
+	}
+	if key := f.inodeKey(); key != (inodeKey{}) {
+		folders[key] = struct{}{}
+	}
+	for _, child := range f.Files {
+		child.collectFolders(folders)
+	}
+}
+
+// isFollowedAgain reports whether the file is a followed link to a folder that is
+// also in the tree at its real place, or was already reached through another link
+func (f *File) isFollowedAgain(seen map[inodeKey]struct{}, folders map[inodeKey]struct{}) bool {
+	if !f.IsDir || f.LinkType != Symlink {
+		return false
+	}
+	key := f.inodeKey()
+	if key == (inodeKey{}) {
+		return false
+	}
+	if _, ok := folders[key]; ok {
+		return true
+	}
+	if _, ok := seen[key]; ok {
+		return true
+	}
+	seen[key] = struct{}{}
+	return false
+}
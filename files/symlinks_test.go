@@ -0,0 +1,80 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createSymlinkTree(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need special privileges on windows")
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "data", "file"), make([]byte, 1000), 0o644))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "data", "file"), filepath.Join(dir, "filelink")))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "data"), filepath.Join(dir, "dirlink")))
+	assert.NoError(t, os.Symlink("..", filepath.Join(dir, "data", "loop")))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken")))
+	return dir
+}
+
+func walkSymlinkTree(dir string, policy SymlinkPolicy) *ScanResult {
+	return WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, make(chan Progress, 10), WalkOptions{Symlinks: policy})
+}
+
+func TestWalkFolderCountSymlinks(t *testing.T) {
+	dir := createSymlinkTree(t)
+	result := walkSymlinkTree(dir, CountSymlinks)
+
+	fileLink := FindTestFile(result.Root, "filelink")
+	assert.Equal(t, Symlink, fileLink.LinkType)
+	assert.Equal(t, filepath.Join(dir, "data", "file"), fileLink.LinkTarget)
+	assert.Equal(t, int64(len(fileLink.LinkTarget)), fileLink.Size)
+	dirLink := FindTestFile(result.Root, "dirlink")
+	assert.False(t, dirLink.IsDir, "links to folders should not be walked")
+
+	broken := FindTestFile(result.Root, "broken")
+	assert.Equal(t, BrokenSymlink, broken.LinkType)
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, BrokenLink, result.ErrorFor(filepath.Join(dir, "broken")).Kind)
+}
+
+func TestWalkFolderSkipSymlinks(t *testing.T) {
+	dir := createSymlinkTree(t)
+	result := walkSymlinkTree(dir, SkipSymlinks)
+
+	assert.Equal(t, int64(1000), result.Root.Size)
+	assert.Equal(t, int64(1), result.Root.NumChildren)
+	assert.Nil(t, FindTestFile(result.Root, "filelink"))
+	assert.Empty(t, result.Errors)
+}
+
+func TestWalkFolderFollowSymlinks(t *testing.T) {
+	dir := createSymlinkTree(t)
+	result := walkSymlinkTree(dir, FollowSymlinks)
+
+	fileLink := FindTestFile(result.Root, "filelink")
+	assert.Equal(t, Symlink, fileLink.LinkType)
+	assert.Equal(t, int64(1000), fileLink.Size)
+
+	dirLink := FindTestFile(result.Root, "dirlink")
+	assert.True(t, dirLink.IsDir)
+	assert.Equal(t, Symlink, dirLink.LinkType)
+	assert.Equal(t, int64(1000), FindTestFile(dirLink, "file").Size)
+	data, broken := FindTestFile(result.Root, "data"), FindTestFile(result.Root, "broken")
+	assert.Equal(t, data.Size+fileLink.Size+broken.Size, result.Root.Size, "the linked folder should only be counted at its real place")
+	assert.Equal(t, int64(3), result.Root.NumChildren-data.NumChildren)
+
+	// data/loop points to the scanned folder so it is kept as a link
+	loop := FindTestFile(FindTestFile(result.Root, "data"), "loop")
+	assert.False(t, loop.IsDir)
+	// dirlink/loop points to an ancestor of the link too
+	loop = FindTestFile(dirLink, "loop")
+	assert.False(t, loop.IsDir)
+}
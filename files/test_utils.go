@@ -3,7 +3,7 @@ package files
 // NewTestFolder is providing easy interface to create folders for automated tests
 // Never use in production code!
 func NewTestFolder(name string, files ...*File) *File {
-	folder := &File{name, 0, true, []*File{}, "", 0, 0}
+	folder := &File{Name: name, IsDir: true, Files: []*File{}}
 	if files == nil {
 		return folder
 	}
@@ -15,7 +15,7 @@ func NewTestFolder(name string, files ...*File) *File {
 // NewTestFile provides easy interface to create files for automated tests
 // Never use in production code!
 func NewTestFile(name string, size int64) *File {
-	return &File{name, size, false, []*File{}, "", 0, 0}
+	return &File{Name: name, Size: size}
 }
 
 // FindTestFile helps testing by returning first occurrence of file with given name.
@@ -7,30 +7,32 @@ import (
 )
 
 func TestBuildFile(t *testing.T) {
-	a := &File{"a", 100, false, []*File{}, "", 0, 0}
+	a := &File{Name: "a", Size: 100}
 	build := NewTestFile("a", 100)
 	assert.Equal(t, a, build)
 }
 
 func TestBuildFolder(t *testing.T) {
-	a := &File{"a", 0, true, []*File{}, "", 0, 0}
+	a := &File{Name: "a", IsDir: true, Files: []*File{}}
 	build := NewTestFolder("a")
 	assert.Equal(t, a, build)
 }
 
 func TestBuildFolderWithFile(t *testing.T) {
-	e := &File{"e", 100, false, []*File{}, "", 0, 0}
-	d := &File{"d", 100, true, []*File{e}, "", 0, 0}
+	e := &File{Name: "e", Size: 100}
+	d := &File{Name: "d", Size: 100, IsDir: true, Files: []*File{e}, NumChildren: 1}
+	e.Parent = d
 	build := NewTestFolder("d", NewTestFile("e", 100))
 	assert.Equal(t, d, build)
 }
 
 func TestBuildComplexFolder(t *testing.T) {
-	e := &File{"e", 100, false, []*File{}, "", 0, 0}
-	d := &File{"d", 100, true, []*File{e}, "", 0, 0}
-	b := &File{"b", 50, false, []*File{}, "", 0, 0}
-	c := &File{"c", 100, false, []*File{}, "", 0, 0}
-	a := &File{"a", 250, true, []*File{b, c, d}, "", 0, 0}
+	e := &File{Name: "e", Size: 100}
+	d := &File{Name: "d", Size: 100, IsDir: true, Files: []*File{e}, Level: 1, NumChildren: 1}
+	b := &File{Name: "b", Size: 50}
+	c := &File{Name: "c", Size: 100}
+	a := &File{Name: "a", Size: 250, IsDir: true, Files: []*File{c, d, b}, NumChildren: 3}
+	e.Parent, b.Parent, c.Parent, d.Parent = d, a, a, a
 	build := NewTestFolder("a", NewTestFile("b", 50), NewTestFile("c", 100), NewTestFolder("d", NewTestFile("e", 100)))
 	assert.Equal(t, a, build)
 }
@@ -42,7 +44,8 @@ func TestFindTestFile(t *testing.T) {
 			NewTestFile("d", 100),
 		),
 	)
-	expected := folder.Files[0].Files[1]
+	// UpdateSize sorts the biggest files first
+	expected := folder.Files[0].Files[0]
 	foundFile := FindTestFile(folder, "d")
 	assert.Equal(t, expected, foundFile)
 }
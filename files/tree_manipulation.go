@@ -1,17 +1,25 @@
 package files
 
 import (
+	"path"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
 // SortDesc sorts folder content by size from largest to smallest
 func SortDesc(folder *File) {
+	SortDescBy(folder, ApparentSize)
+}
+
+// SortDescBy sorts folder content from largest to smallest using the given size metric
+func SortDescBy(folder *File, metric SizeMetric) {
 	sort.Slice(folder.Files,
 		func(i, j int) bool {
-			return folder.Files[i].Size > folder.Files[j].Size
+			return folder.Files[i].SizeBy(metric) > folder.Files[j].SizeBy(metric)
 		})
 	for _, file := range folder.Files {
-		SortDesc(file)
+		SortDescBy(file, metric)
 	}
 }
 
@@ -19,10 +27,138 @@ func PruneSmallFiles(folder *File, limit int64) {
 	prunedFiles := []*File{}
 	for _, file := range folder.Files {
 		if file.Size >= limit {
-			PruneSmallFiles(file, limit)
+			if file.IsDir {
+				PruneSmallFiles(file, limit)
+			}
 			prunedFiles = append(prunedFiles, file)
 		}
 	}
 	folder.Files = prunedFiles
 
 }
+
+// Remove detaches target, which must be inside f, from the tree and subtracts its size
+// and number of children from all the folders containing it. If it contains hard links
+// or followed links to folders, the sizes of the whole tree are calculated again
+func (f *File) Remove(target *File) error {
+	if target == f {
+		return ErrNotInTree
+	}
+	ancestors, found := f.pathTo(target)
+	if !found {
+		return ErrNotInTree
+	}
+
+	parent := ancestors[len(ancestors)-1]
+	for i, child := range parent.Files {
+		if child == target {
+			parent.Files = append(parent.Files[:i], parent.Files[i+1:]...)
+			break
+		}
+	}
+	target.Parent = nil
+
+	if target.hasSharedContent() {
+		// Which link counts the shared content depends on the rest of the tree
+		f.UpdateSize(f.Level)
+		return nil
+	}
+	var numchildren int64 = 1
+	if target.IsDir {
+		numchildren = target.NumChildren
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		ancestor := ancestors[i]
+		ancestor.Size -= target.Size
+		ancestor.Usage -= target.Usage
+		ancestor.NumChildren -= numchildren
+		ancestor.updateFromChildren()
+	}
+	return nil
+}
+
+// Path returns the path of the file, built from the names of the folders containing it.
+// The name of the root is the path that was scanned. Trees of an fs.FS are slash separated
+func (f *File) Path() string {
+	if f.Parent == nil {
+		return f.Name
+	}
+	var names []string
+	root := f
+	for file := f; file != nil; file = file.Parent {
+		names = append(names, file.Name)
+		root = file
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	if root.SlashSeparated {
+		return path.Join(names...)
+	}
+	return filepath.Join(names...)
+}
+
+// Lookup returns the file found at path, which must be f or be inside f,
+// or nil if there is no such file in the tree
+func (f *File) Lookup(path string) *File {
+	rel, separator, ok := f.relativePath(path)
+	if !ok {
+		return nil
+	}
+	if rel == "." {
+		return f
+	}
+
+	file := f
+	for _, name := range strings.Split(rel, separator) {
+		var child *File
+		for _, candidate := range file.Files {
+			if candidate.Name == name {
+				child = candidate
+				break
+			}
+		}
+		if child == nil {
+			return nil
+		}
+		file = child
+	}
+	return file
+}
+
+// relativePath returns target relative to the path of f, with the separator used by
+// the tree, and whether target is f or is inside f
+func (f *File) relativePath(target string) (string, string, bool) {
+	root := f
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if !root.SlashSeparated {
+		rel, err := filepath.Rel(f.Path(), target)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", "", false
+		}
+		return rel, string(filepath.Separator), true
+	}
+
+	base, target := path.Clean(f.Path()), path.Clean(target)
+	switch {
+	case base == target:
+		return ".", "/", true
+	case base == ".":
+		return target, "/", target != ".." && !strings.HasPrefix(target, "../") && !path.IsAbs(target)
+	case strings.HasPrefix(target, strings.TrimSuffix(base, "/")+"/"):
+		return target[len(strings.TrimSuffix(base, "/"))+1:], "/", true
+	}
+	return "", "", false
+}
+
+// IsInside checks if the file is inside folder, at any depth
+func (f *File) IsInside(folder *File) bool {
+	for parent := f.Parent; parent != nil; parent = parent.Parent {
+		if parent == folder {
+			return true
+		}
+	}
+	return false
+}
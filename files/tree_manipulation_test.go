@@ -1,6 +1,7 @@
 package files
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,23 +36,115 @@ func TestSortFolder(t *testing.T) {
 }
 
 func TestPruneFolder(t *testing.T) {
-	folder := &File{"b", 260, true, []*File{
-		{"c", 100, false, []*File{}, "", 1, 0},
-		{"d", 160, true, []*File{
-			{"e", 50, false, []*File{}, "", 2, 0},
-			{"f", 30, false, []*File{}, "", 2, 0},
-			{"g", 80, true, []*File{
-				{"i", 50, false, []*File{}, "", 3, 0},
-				{"j", 30, false, []*File{}, "", 3, 0},
-			}, "", 2, 0},
-		}, "", 1, 0},
-	}, "", 0, 0}
-	expected := &File{"b", 260, true, []*File{
-		{"c", 100, false, []*File{}, "", 1, 0},
-		{"d", 160, true, []*File{
-			{"g", 80, true, []*File{}, "", 2, 0},
-		}, "", 1, 0},
-	}, "", 0, 0}
+	folder := &File{Name: "b", Size: 260, IsDir: true, Files: []*File{
+		{Name: "c", Size: 100, Level: 1},
+		{Name: "d", Size: 160, IsDir: true, Files: []*File{
+			{Name: "e", Size: 50, Level: 2},
+			{Name: "f", Size: 30, Level: 2},
+			{Name: "g", Size: 80, IsDir: true, Files: []*File{
+				{Name: "i", Size: 50, Level: 3},
+				{Name: "j", Size: 30, Level: 3},
+			}, Level: 2},
+		}, Level: 1},
+	}, Level: 0}
+	expected := &File{Name: "b", Size: 260, IsDir: true, Files: []*File{
+		{Name: "c", Size: 100, Level: 1},
+		{Name: "d", Size: 160, IsDir: true, Files: []*File{
+			{Name: "g", Size: 80, IsDir: true, Files: []*File{}, Level: 2},
+		}, Level: 1},
+	}, Level: 0}
 	PruneSmallFiles(folder, 60)
 	assert.Equal(t, expected, folder)
 }
+
+func TestRemoveFile(t *testing.T) {
+	folder := NewTestFolder("b",
+		NewTestFile("c", 100),
+		NewTestFolder("d",
+			NewTestFile("e", 50),
+			NewTestFile("f", 30),
+			NewTestFolder("g",
+				NewTestFile("i", 60),
+				NewTestFile("j", 50),
+			),
+		),
+	)
+	g := FindTestFile(folder, "g")
+	assert.NoError(t, folder.Remove(g))
+	assert.Nil(t, FindTestFile(folder, "g"))
+	assert.Equal(t, int64(180), folder.Size)
+	assert.Equal(t, int64(3), folder.NumChildren)
+	d := FindTestFile(folder, "d")
+	assert.Equal(t, int64(80), d.Size)
+	assert.Equal(t, int64(2), d.NumChildren)
+	assert.Equal(t, "c", folder.Files[0].Name, "folders should be sorted again")
+
+	assert.NoError(t, folder.Remove(FindTestFile(folder, "e")))
+	assert.Equal(t, int64(130), folder.Size)
+	assert.Equal(t, int64(2), folder.NumChildren)
+	assert.Equal(t, []*File{FindTestFile(folder, "f")}, d.Files)
+}
+
+func TestRemoveNotInTree(t *testing.T) {
+	folder := NewTestFolder("b", NewTestFile("c", 100))
+	assert.ErrorIs(t, folder.Remove(NewTestFile("c", 100)), ErrNotInTree)
+	assert.ErrorIs(t, folder.Remove(folder), ErrNotInTree, "the root cannot be removed")
+	assert.Equal(t, int64(100), folder.Size)
+}
+
+func TestParent(t *testing.T) {
+	folder := NewTestFolder("b",
+		NewTestFile("c", 100),
+		NewTestFolder("d",
+			NewTestFile("e", 50),
+		),
+	)
+	d := FindTestFile(folder, "d")
+	assert.Nil(t, folder.Parent)
+	assert.Same(t, folder, d.Parent)
+	assert.Same(t, d, FindTestFile(folder, "e").Parent)
+
+	assert.NoError(t, folder.Remove(d))
+	assert.Nil(t, d.Parent, "removed files should not point to the tree")
+}
+
+func TestPath(t *testing.T) {
+	folder := NewTestFolder(filepath.Join("a", "b"),
+		NewTestFolder("d",
+			NewTestFile("e", 50),
+		),
+	)
+	assert.Equal(t, filepath.Join("a", "b"), folder.Path())
+	assert.Equal(t, filepath.Join("a", "b", "d", "e"), FindTestFile(folder, "e").Path())
+}
+
+func TestLookup(t *testing.T) {
+	folder := NewTestFolder(filepath.Join("a", "b"),
+		NewTestFile("c", 100),
+		NewTestFolder("d",
+			NewTestFile("e", 50),
+		),
+	)
+	d := FindTestFile(folder, "d")
+	assert.Same(t, folder, folder.Lookup(filepath.Join("a", "b")))
+	assert.Same(t, FindTestFile(folder, "e"), folder.Lookup(filepath.Join("a", "b", "d", "e")))
+	assert.Same(t, FindTestFile(folder, "e"), d.Lookup(filepath.Join("a", "b", "d", "e")), "subfolders should find what is inside them")
+	assert.Nil(t, folder.Lookup(filepath.Join("a", "b", "x")))
+	assert.Nil(t, d.Lookup(filepath.Join("a", "b", "c")), "files outside the folder should not be found")
+	assert.Nil(t, folder.Lookup(filepath.Join("a", "bc")))
+}
+
+func TestWalkFolderPaths(t *testing.T) {
+	testStructure := fakeFile{"a", 0, []fakeFile{
+		{"b", 0, []fakeFile{
+			{"c", 100, []fakeFile{}},
+			{"d", 0, []fakeFile{
+				{"e", 50, []fakeFile{}},
+			}},
+		}},
+	}}
+	root := WalkFolder("b", createReadDir(testStructure), func(string) bool { return false }, nil, WalkOptions{}).Root
+	e := FindTestFile(root, "e")
+	assert.Equal(t, filepath.Join("b", "d", "e"), e.Path())
+	assert.Same(t, e, root.Lookup(e.Path()))
+}
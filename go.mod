@@ -1,6 +1,6 @@
 module gocleasy
 
-go 1.18
+go 1.20
 
 require (
 	gioui.org v0.1.0
@@ -3,7 +3,10 @@ package guiutils
 import (
 	"embed"
 	"fmt"
+	"gocleasy/cleanup"
 	"gocleasy/files"
+	"gocleasy/protect"
+	"gocleasy/quarantine"
 	"image"
 	"path/filepath"
 	"time"
@@ -28,14 +31,46 @@ const (
 	LoadingFilesS State = "loadingFilesS" // Show the files to be selected
 	SelFilesS     State = "selFileS"      // Show the files to be selected
 	DelFilesS     State = "delFileS"      // Show the selected files to be deleted
+	DiffS         State = "diffS"         // Show what changed since a previous snapshot
+	DeletingS     State = "deletingS"     // Show that the selected files are being deleted
+	DelResultsS   State = "delResultsS"   // Show what happened to each file deleted
+	QuarantineS   State = "quarantineS"   // Show the files in quarantine to restore them
 )
 
 type AppLogic struct {
-	theme      *material.Theme   // Store the them of the application
-	Files      *files.File       // Used to store the files with their structure
-	Selfiles   []*files.File     // Used to store the files that has been selected
-	Files2Show []*files.FileShow // Used to store the filest that are going to be rendered
-	Appstate   State
+	theme        *material.Theme     // Store the them of the application
+	Files        *files.File         // Used to store the files with their structure
+	Selection    cleanup.Selection   // Used to store the files that has been selected
+	Files2Show   []*files.FileShow   // Used to store the filest that are going to be rendered
+	ScanErrors   []*files.ScanError  // Used to store the paths that could not be read in the scan
+	SizeMetric   files.SizeMetric    // Used to choose between apparent size and disk usage
+	Columns      Columns             // Used to choose the optional columns of the selection table
+	ScanProgress files.Progress      // Used to show how far the scan has gone
+	ScanStarted  time.Time           // Used to show the elapsed time and throughput of the scan
+	ScanEstimate *files.ScanEstimate // Used to show the percentage and ETA of the scan, nil if unknown
+	ScanFraction float64             // Fraction of the scan already done when there is an estimate
+	ScanETA      time.Duration       // Estimated time to finish the scan when there is an estimate
+	ScanConfig   ScanConfig          // Used to scan again folders the same way they were scanned
+
+	FolderToRefresh *files.File // Folder whose Refresh button was clicked, nil until it is scanned again
+
+	DeletionReport  *cleanup.Report // What happened in the last deletion, nil if nothing was deleted
+	DeletionPlan    *cleanup.Plan   // What deleting the selection would do, nil until a dry run is made
+	SnapshotMessage string          // Result of the last snapshot saved or loaded
+	Plan            bool            // The files were imported from another computer, they can only be selected to plan what to delete
+
+	Guard     *protect.Guard // Paths that cannot be deleted, the scan root among them
+	Protected []error        // Why the selection cannot be deleted, empty if it does not touch protected paths
+
+	Delta       *files.Delta // Changes since a previous snapshot
+	DeltaSince  time.Time    // When the previous snapshot was made
+	Deltas2Show []*DeltaShow // Used to store the changes that are going to be rendered
+
+	Quarantine          []*QuarantineShow // Batches of files in quarantine, the newest first
+	QuarantineMessage   string            // Result of the last restore or purge
+	QuarantineRetention time.Duration     // How long quarantined files are kept before purging them
+
+	Appstate State
 }
 
 type C = layout.Context
@@ -45,23 +80,29 @@ type D = layout.Dimensions
 func NewAppLogic() *AppLogic {
 
 	return &AppLogic{
-		theme:    material.NewTheme(gofont.Collection()),
-		Appstate: HomeS,
+		theme:               material.NewTheme(gofont.Collection()),
+		QuarantineRetention: quarantine.DefaultRetention,
+		Appstate:            HomeS,
 	}
 }
 
-func (applogic *AppLogic) ReportProgress(win *app.Window, total *int, progress <-chan int) {
+func (applogic *AppLogic) ReportProgress(win *app.Window, progress <-chan files.Progress) {
 
 	// Controls how frequently to update the application
 	const interval = 250 * time.Millisecond
 	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	for {
 		select {
-		case loadedFile, ok := <-progress:
+		case event, ok := <-progress:
 
 			if ok && (applogic.Appstate == LoadingFilesS) {
-				*total += loadedFile
+				applogic.ScanProgress = event
+				if applogic.ScanEstimate != nil {
+					applogic.ScanFraction = applogic.ScanEstimate.Fraction(event)
+					applogic.ScanETA = applogic.ScanEstimate.Remaining(event, time.Since(applogic.ScanStarted))
+				}
 			} else if !ok {
 				applogic.Appstate = SelFilesS
 				win.Invalidate()
@@ -88,7 +129,7 @@ func showGocleasyLogo(gtx C, margins layout.Inset) layout.FlexChild {
 		// Pass the file to the Decode function
 		img, format, err := image.Decode(file)
 		if err != nil {
-			fmt.Println("Format: %s; Error decoding image: %s", format, err)
+			fmt.Printf("Format: %s; Error decoding image: %s\n", format, err)
 		}
 
 		return margins.Layout(gtx, func(gtx C) D {
@@ -100,7 +141,7 @@ func showGocleasyLogo(gtx C, margins layout.Inset) layout.FlexChild {
 	})
 }
 
-func (applogic *AppLogic) HomePage(gtx C, scanbutton *widget.Clickable, initialpathinput *widget.Editor, numfilesdeleted int64, sizeliberated int64) D {
+func (applogic *AppLogic) HomePage(gtx C, scanbutton *widget.Clickable, loadsnapshotbutton *widget.Clickable, importncdubutton *widget.Clickable, quarantinebutton *widget.Clickable, initialpathinput *widget.Editor, onefilesystem *widget.Bool, symlinkpolicy *widget.Enum) D {
 
 	margins := layout.Inset{
 		Top:    unit.Dp(25),
@@ -109,13 +150,6 @@ func (applogic *AppLogic) HomePage(gtx C, scanbutton *widget.Clickable, initialp
 		Left:   unit.Dp(25),
 	}
 
-	var deletedfilesoutput string
-	if numfilesdeleted == 0 || sizeliberated == 0 {
-		deletedfilesoutput = ""
-	} else {
-		deletedfilesoutput = fmt.Sprintf("   Deleted %s files and %s", humanize.Comma(numfilesdeleted), humanize.Bytes(uint64(sizeliberated)))
-	}
-
 	return layout.Flex{
 		Axis:      layout.Vertical,
 		Alignment: layout.Middle,
@@ -123,24 +157,98 @@ func (applogic *AppLogic) HomePage(gtx C, scanbutton *widget.Clickable, initialp
 	}.Layout(gtx,
 		showGocleasyLogo(gtx, margins),
 		layout.Rigid(func(gtx C) D {
-			return material.Body1(applogic.theme, deletedfilesoutput).Layout(gtx)
+			return layout.Inset{
+				Right: unit.Dp(25),
+				Left:  unit.Dp(25),
+			}.Layout(gtx, func(gtx C) D {
+				return material.Editor(applogic.theme, initialpathinput, " Introduce Initial Path. Leave blank for root path.").Layout(gtx)
+			})
 		}),
 		layout.Rigid(func(gtx C) D {
 			return layout.Inset{
+				Top:   unit.Dp(10),
 				Right: unit.Dp(25),
 				Left:  unit.Dp(25),
 			}.Layout(gtx, func(gtx C) D {
-				return material.Editor(applogic.theme, initialpathinput, " Introduce Initial Path. Leave blank for root path.").Layout(gtx)
+				return material.CheckBox(applogic.theme, onefilesystem, "Stay on one filesystem").Layout(gtx)
 			})
 		}),
+		// Choose what to do with symbolic links
+		layout.Rigid(func(gtx C) D {
+			return layout.Inset{
+				Right: unit.Dp(25),
+				Left:  unit.Dp(25),
+			}.Layout(gtx, func(gtx C) D {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return material.Body1(applogic.theme, "Symbolic links:").Layout(gtx)
+					}),
+					symlinkPolicyRadio(applogic.theme, symlinkpolicy, files.CountSymlinks),
+					symlinkPolicyRadio(applogic.theme, symlinkpolicy, files.SkipSymlinks),
+					symlinkPolicyRadio(applogic.theme, symlinkpolicy, files.FollowSymlinks),
+				)
+			})
+		}),
+		// Message of the last snapshot that could not be loaded
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(applogic.theme, applogic.SnapshotMessage).Layout(gtx)
+		}),
 		layout.Rigid(func(gtx C) D {
 			return margins.Layout(gtx, func(gtx C) D {
-				return material.Button(applogic.theme, scanbutton, "Scan Files").Layout(gtx)
+				return layout.Flex{
+					Axis:    layout.Horizontal,
+					Spacing: layout.SpaceBetween,
+				}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, loadsnapshotbutton, "Load Snapshot").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, importncdubutton, "Import ncdu").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, quarantinebutton, "Quarantine").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, scanbutton, "Scan Files").Layout(gtx)
+					}),
+				)
 			})
 		}),
 	)
 }
 
+func symlinkPolicyRadio(th *material.Theme, symlinkpolicy *widget.Enum, policy files.SymlinkPolicy) layout.FlexChild {
+	return layout.Rigid(func(gtx C) D {
+		return material.RadioButton(th, symlinkpolicy, policy.String(), policy.String()).Layout(gtx)
+	})
+}
+
+// Returns the symbolic link policy chosen in the home page
+func SelectedSymlinkPolicy(symlinkpolicy *widget.Enum) files.SymlinkPolicy {
+	for _, policy := range []files.SymlinkPolicy{files.SkipSymlinks, files.FollowSymlinks} {
+		if symlinkpolicy.Value == policy.String() {
+			return policy
+		}
+	}
+	return files.CountSymlinks
+}
+
+func deleteModeRadio(th *material.Theme, deletemode *widget.Enum, mode cleanup.Mode) layout.FlexChild {
+	return layout.Rigid(func(gtx C) D {
+		return material.RadioButton(th, deletemode, mode.String(), mode.String()).Layout(gtx)
+	})
+}
+
+// Returns the way to delete the files chosen in the deleting page
+func SelectedDeleteMode(deletemode *widget.Enum) cleanup.Mode {
+	for _, mode := range cleanup.Modes {
+		if deletemode.Value == mode.String() {
+			return mode
+		}
+	}
+	return cleanup.DefaultMode
+}
+
 func createTextNLoading(gtx C, th *material.Theme, text string) layout.FlexChild {
 	return layout.Rigid(func(gtx C) D {
 		return layout.Flex{
@@ -150,7 +258,7 @@ func createTextNLoading(gtx C, th *material.Theme, text string) layout.FlexChild
 				layout.Spacer{Width: unit.Dp(25)}.Layout,
 			),
 			layout.Rigid(func(gtx C) D {
-				return material.Body1(th, fmt.Sprintf("Loading file \"%s\"...", text)).Layout(gtx)
+				return material.Body1(th, fmt.Sprintf("Reading \"%s\"...", text)).Layout(gtx)
 			}),
 			layout.Rigid(
 				layout.Spacer{Width: unit.Dp(25)}.Layout,
@@ -174,7 +282,39 @@ func (applogic *AppLogic) FillFirstLayer2Show() {
 	}
 }
 
-func (applogic *AppLogic) ShowLoadingPage(gtx C, actualFilesRead int) D {
+// Rebuilds Files2Show from the file tree keeping the rows that were already shown,
+// so opened folders stay opened. Used when the order or content of the tree changes
+func (applogic *AppLogic) refreshFiles2Show() {
+
+	shown := make(map[*files.File]*files.FileShow, len(applogic.Files2Show))
+	for _, file := range applogic.Files2Show {
+		shown[file.File] = file
+	}
+	applogic.Files2Show = applogic.appendFiles2Show(nil, applogic.Files.Files, shown)
+}
+
+func (applogic *AppLogic) appendFiles2Show(rows []*files.FileShow, children []*files.File, shown map[*files.File]*files.FileShow) []*files.FileShow {
+
+	for _, file := range children {
+		row, ok := shown[file]
+		if !ok {
+			row = &files.FileShow{
+				File:         file,
+				IsSelected:   widget.Bool{Value: applogic.Selection.IsSelected(file)},
+				ActionButton: widget.Bool{},
+			}
+		}
+		rows = append(rows, row)
+
+		// Keep showing the content of opened folders
+		if file.IsDir && row.ActionButton.Value {
+			rows = applogic.appendFiles2Show(rows, file.Files, shown)
+		}
+	}
+	return rows
+}
+
+func (applogic *AppLogic) ShowLoadingPage(gtx C, cancelbutton *widget.Clickable) D {
 
 	margins := layout.Inset{
 		Top:    unit.Dp(25),
@@ -194,16 +334,84 @@ func (applogic *AppLogic) ShowLoadingPage(gtx C, actualFilesRead int) D {
 		),
 		showGocleasyLogo(gtx, margins),
 		// Show Reading files and loading circle
-		createTextNLoading(gtx, applogic.theme, fmt.Sprintf("%d", actualFilesRead)),
-		layout.Rigid(
-			layout.Spacer{Height: unit.Dp(25)}.Layout,
-		),
+		createTextNLoading(gtx, applogic.theme, applogic.ScanProgress.CurrentPath),
+		// Show what has been found so far and how fast
+		layout.Rigid(func(gtx C) D {
+			return applogic.scanStatistics(gtx)
+		}),
+		// Show percentage and ETA if the size of the scan is known
+		layout.Rigid(func(gtx C) D {
+			return applogic.scanCompletion(gtx)
+		}),
+		// Button to stop the scan and show what has been read so far
+		layout.Rigid(func(gtx C) D {
+			return margins.Layout(gtx, func(gtx C) D {
+				return material.Button(applogic.theme, cancelbutton, "Cancel scan").Layout(gtx)
+			})
+		}),
 	)
 }
 
-func selectFilesTableRow(th *material.Theme, file *files.FileShow, numchildren string, filepath string) []layout.FlexChild {
+// Counters of the scan in progress together with its elapsed time and throughput
+func (applogic *AppLogic) scanStatistics(gtx C) D {
+
+	progress := applogic.ScanProgress
+	elapsed := time.Since(applogic.ScanStarted).Truncate(time.Second)
+	var bytespersecond, filespersecond int64
+	if seconds := time.Since(applogic.ScanStarted).Seconds(); seconds > 0 {
+		bytespersecond = int64(float64(progress.Bytes) / seconds)
+		filespersecond = int64(float64(progress.FilesCounted) / seconds)
+	}
+
+	return layout.Inset{
+		Top:   unit.Dp(10),
+		Right: unit.Dp(25),
+		Left:  unit.Dp(25),
+	}.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, fmt.Sprintf("%s folders, %s files, %s errors",
+					humanize.Comma(progress.DirsScanned), humanize.Comma(progress.FilesCounted), humanize.Comma(progress.Errors))).Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, fmt.Sprintf("%s found in %s (%s/s, %s files/s)",
+					humanize.Bytes(uint64(progress.Bytes)), elapsed, humanize.Bytes(uint64(bytespersecond)), humanize.Comma(filespersecond))).Layout(gtx)
+			}),
+		)
+	})
+}
+
+// Progress bar with percentage and remaining time, nothing if the scan is indeterminate
+func (applogic *AppLogic) scanCompletion(gtx C) D {
 
-	return []layout.FlexChild{
+	if applogic.ScanEstimate == nil {
+		return D{}
+	}
+
+	var remaining string = "estimating time left"
+	if applogic.ScanFraction > 0 {
+		remaining = fmt.Sprintf("about %s left", applogic.ScanETA.Truncate(time.Second))
+	}
+
+	return layout.Inset{
+		Top:   unit.Dp(10),
+		Right: unit.Dp(25),
+		Left:  unit.Dp(25),
+	}.Layout(gtx, func(gtx C) D {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx C) D {
+				return material.ProgressBar(applogic.theme, float32(applogic.ScanFraction)).Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, fmt.Sprintf("%.0f%%, %s", applogic.ScanFraction*100, remaining)).Layout(gtx)
+			}),
+		)
+	})
+}
+
+func selectFilesTableRow(th *material.Theme, file *files.FileShow, numchildren string, filepath string, metric files.SizeMetric, columns *Columns, canrefresh bool) []layout.FlexChild {
+
+	row := []layout.FlexChild{
 		// Name of the file
 		layout.Rigid(func(gtx C) D {
 			return material.CheckBox(th, &file.IsSelected, "").Layout(gtx)
@@ -214,6 +422,14 @@ func selectFilesTableRow(th *material.Theme, file *files.FileShow, numchildren s
 		}),
 		// Ocupy the space in between buttons and text (checkbox and filenames, size and numfiles)
 		layout.Flexed(1, layout.Spacer{}.Layout),
+		// Button to scan the folder again
+		layout.Rigid(func(gtx C) D {
+			if !file.File.IsDir || !canrefresh {
+				return D{}
+			}
+			return material.Button(th, &file.RefreshButton, "Refresh").Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
 		// Num of files inside the directory (0 if it is a file)
 		layout.Rigid(func(gtx C) D {
 			return material.Body1(th, numchildren).Layout(gtx)
@@ -221,18 +437,16 @@ func selectFilesTableRow(th *material.Theme, file *files.FileShow, numchildren s
 		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
 		// Size of the file
 		layout.Rigid(func(gtx C) D {
-			return material.Body1(th, humanize.Bytes(uint64(file.File.Size))).Layout(gtx)
+			return material.Body1(th, humanize.Bytes(uint64(file.File.SizeBy(metric)))).Layout(gtx)
 		}),
 	}
+	// Optional columns after the size
+	return append(row, columns.row(th, file.File)...)
 }
 
-func selectFilesTableHeader(gtx C, th *material.Theme) D {
+func selectFilesTableHeader(gtx C, th *material.Theme, metric files.SizeMetric, columns *Columns) D {
 
-	return layout.Flex{
-		Axis:      layout.Horizontal,
-		Alignment: layout.Middle,
-		Spacing:   layout.SpaceStart,
-	}.Layout(gtx,
+	header := []layout.FlexChild{
 		layout.Rigid(layout.Spacer{Width: unit.Dp(75)}.Layout),
 		// Name of the file
 		layout.Rigid(func(gtx C) D {
@@ -247,9 +461,16 @@ func selectFilesTableHeader(gtx C, th *material.Theme) D {
 		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
 		// Size of the file
 		layout.Rigid(func(gtx C) D {
-			return material.Body1(th, "Size").Layout(gtx)
+			return material.Body1(th, metric.String()).Layout(gtx)
 		}),
-	)
+	}
+	header = append(header, columns.header(th)...)
+
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+		Spacing:   layout.SpaceStart,
+	}.Layout(gtx, header...)
 }
 
 func deleteFilesTableRow(gtx C, th *material.Theme, field1 string, field2 string, field3 string) D {
@@ -277,24 +498,92 @@ func deleteFilesTableRow(gtx C, th *material.Theme, field1 string, field2 string
 	)
 }
 
-func (applogic *AppLogic) ShowFiles(gtx C, nextbutton *widget.Clickable, filelist *widget.List) D {
+func (applogic *AppLogic) ShowFiles(gtx C, nextbutton *widget.Clickable, newscanbutton *widget.Clickable, savesnapshotbutton *widget.Clickable, exportncdubutton *widget.Clickable, comparebutton *widget.Clickable, filelist *widget.List, showskipped *widget.Bool, skippedlist *widget.List, diskusage *widget.Bool) D {
+
+	// Switch between apparent size and disk usage, biggest files first
+	if diskusage.Changed() {
+		if diskusage.Value {
+			applogic.SizeMetric = files.DiskUsage
+		} else {
+			applogic.SizeMetric = files.ApparentSize
+		}
+		files.SortDescBy(applogic.Files, applogic.SizeMetric)
+		applogic.refreshFiles2Show()
+	}
 
 	var widgets []layout.FlexChild = []layout.FlexChild{
 		// Space on the top of the window
 		layout.Rigid(
 			layout.Spacer{Height: unit.Dp(25)}.Layout,
 		),
+	}
+
+	// Show where the files come from or where they were saved
+	if applogic.SnapshotMessage != "" {
+		widgets = append(widgets,
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, applogic.SnapshotMessage).Layout(gtx)
+			}),
+		)
+	}
+
+	// Show what the last deletion freed, the tree does not contain those files anymore
+	if applogic.DeletionReport != nil && len(applogic.DeletionReport.Results) > 0 {
+		widgets = append(widgets,
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, applogic.deletionSummary()).Layout(gtx)
+			}),
+			layout.Rigid(
+				layout.Spacer{Height: unit.Dp(10)}.Layout,
+			),
+		)
+	}
+
+	// Warn that sizes are understated if the scan was cancelled
+	if applogic.Files != nil && applogic.Files.Incomplete {
+		widgets = append(widgets,
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(applogic.theme, "Scan cancelled, the results are incomplete").Layout(gtx)
+			}),
+			layout.Rigid(
+				layout.Spacer{Height: unit.Dp(10)}.Layout,
+			),
+		)
+	}
+
+	// Show how many paths were skipped and, if asked, which ones
+	if len(applogic.ScanErrors) > 0 {
+		widgets = append(widgets,
+			layout.Rigid(func(gtx C) D {
+				text := fmt.Sprintf("%s problems found while scanning", humanize.Comma(int64(len(applogic.ScanErrors))))
+				return material.CheckBox(applogic.theme, showskipped, text).Layout(gtx)
+			}),
+		)
+		if showskipped.Value {
+			widgets = append(widgets,
+				layout.Rigid(func(gtx C) D {
+					return applogic.skippedPaths(gtx, skippedlist)
+				}),
+			)
+		}
+	}
+
+	widgets = append(widgets,
+		layout.Rigid(func(gtx C) D {
+			return material.CheckBox(applogic.theme, diskusage, "Show disk usage instead of apparent size").Layout(gtx)
+		}),
+		applogic.Columns.checkboxes(applogic.theme),
 		layout.Rigid(func(gtx C) D {
-			return selectFilesTableHeader(gtx, applogic.theme)
+			return selectFilesTableHeader(gtx, applogic.theme, applogic.SizeMetric, &applogic.Columns)
 		}),
 		// Where files are shown
 		layout.Flexed(1, func(gtx C) D {
 			return applogic.fileTree(gtx, filelist, "")
 		}),
-	}
+	)
 
 	widgets = append(widgets,
-		// Button to confirm selected files
+		// Buttons to scan another path, to save the scan and to confirm selected files
 		layout.Rigid(func(gtx C) D {
 			margins := layout.Inset{
 				Top:    unit.Dp(25),
@@ -303,7 +592,26 @@ func (applogic *AppLogic) ShowFiles(gtx C, nextbutton *widget.Clickable, filelis
 				Left:   unit.Dp(35),
 			}
 			return margins.Layout(gtx, func(gtx C) D {
-				return material.Button(applogic.theme, nextbutton, "Next").Layout(gtx)
+				return layout.Flex{
+					Axis:    layout.Horizontal,
+					Spacing: layout.SpaceBetween,
+				}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, newscanbutton, "New Scan").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, savesnapshotbutton, "Save Snapshot").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, exportncdubutton, "Export ncdu").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, comparebutton, "What Grew").Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return material.Button(applogic.theme, nextbutton, "Next").Layout(gtx)
+					}),
+				)
 			})
 		}))
 
@@ -314,15 +622,16 @@ func (applogic *AppLogic) ShowFiles(gtx C, nextbutton *widget.Clickable, filelis
 	}.Layout(gtx, widgets...)
 }
 
-// Checks if ref is inside slf
-func isFileSelected(ref *files.File, slf []*files.File) bool {
+// List of the paths that could not be read during the scan
+func (applogic *AppLogic) skippedPaths(gtx C, skippedlist *widget.List) D {
 
-	for _, file := range slf {
-		if file == ref {
-			return true
-		}
-	}
-	return false
+	// Do not let the list take the space of the file tree
+	gtx.Constraints.Max.Y = gtx.Dp(unit.Dp(150))
+
+	return skippedlist.List.Layout(gtx, len(applogic.ScanErrors), func(gtx C, index int) D {
+		scanerror := applogic.ScanErrors[index]
+		return deleteFilesTableRow(gtx, applogic.theme, scanerror.Path, "", scanerror.Kind.String())
+	})
 }
 
 // Calculates how many files need to be deleted from the slice when a folder is closed
@@ -344,16 +653,22 @@ func getNumFiles2NotShow(pos int, level int, sl []*files.FileShow) int {
 }
 
 // It loops over Files2Show and checks if there is any checkbox has been clicked to open a folder.
-// It also checks if any folder/file has been selected and adds it to Selfiles
+// It also checks if any folder/file has been selected and adds it to the selection
 func (applogic *AppLogic) getFiles2Show() {
 
 	var file *files.FileShow
+	var folder2refresh *files.File
 
 	index := 0
 	for index < len(applogic.Files2Show) {
 
 		file = applogic.Files2Show[index]
 
+		// Check folders to scan again, done after the loop because it changes Files2Show
+		if file.File.IsDir && file.RefreshButton.Clicked() && !applogic.Plan {
+			folder2refresh = file.File
+		}
+
 		// Check Open/Close folders
 		if file.ActionButton.Changed() && file.File.IsDir {
 			if file.ActionButton.Value {
@@ -366,7 +681,7 @@ func (applogic *AppLogic) getFiles2Show() {
 					// Check if the file was selected before to add it selected
 					slice2add = append(slice2add, &files.FileShow{
 						File:         file2append,
-						IsSelected:   widget.Bool{Value: isFileSelected(file2append, applogic.Selfiles)},
+						IsSelected:   widget.Bool{Value: applogic.Selection.IsSelected(file2append)},
 						ActionButton: widget.Bool{},
 					})
 				}
@@ -384,23 +699,20 @@ func (applogic *AppLogic) getFiles2Show() {
 		// Check selected files
 		if file.IsSelected.Changed() {
 			if file.IsSelected.Value {
-				// Add file to Selfiles
-				applogic.Selfiles = append(applogic.Selfiles, file.File)
-
+				applogic.Selection.Select(file.File)
 			} else {
-				// Delete file from Selfiles
-				for id, delfile := range applogic.Selfiles {
-					if delfile == file.File {
-						applogic.Selfiles = append(applogic.Selfiles[:id], applogic.Selfiles[id+1:]...)
-					}
-				}
-
+				applogic.Selection.Unselect(file.File)
 			}
 		}
 
 		index++
 
 	}
+
+	// The folder is scanned again outside the layout, showing the loading page
+	if folder2refresh != nil {
+		applogic.FolderToRefresh = folder2refresh
+	}
 }
 
 // Contains the file Tree
@@ -423,17 +735,31 @@ func (applogic *AppLogic) fileTree(gtx C, filelist *widget.List, path string) D
 
 		spacers = append(spacers, layout.Rigid(layout.Spacer{Width: unit.Dp(file.File.Level * 25)}.Layout))
 
+		var filename string = filepath.Join(path, file.File.Name)
 		if file.File.IsDir {
-			widgets = selectFilesTableRow(applogic.theme, file, humanize.Comma(file.File.NumChildren), fmt.Sprintf("%s/", filepath.Join(path, file.File.Name)))
+			filename = fmt.Sprintf("%s/", filename)
+		}
+		if file.File.LinkType != files.NotLink {
+			filename = fmt.Sprintf("%s -> %s", filename, file.File.LinkTarget)
+		}
+
+		if file.File.Unreadable {
+			widgets = selectFilesTableRow(applogic.theme, file, "unreadable", filename, applogic.SizeMetric, &applogic.Columns, !applogic.Plan)
+		} else if file.File.LinkType == files.BrokenSymlink {
+			widgets = selectFilesTableRow(applogic.theme, file, "broken link", filename, applogic.SizeMetric, &applogic.Columns, !applogic.Plan)
+		} else if file.File.IsDir {
+			widgets = selectFilesTableRow(applogic.theme, file, humanize.Comma(file.File.NumChildren), filename, applogic.SizeMetric, &applogic.Columns, !applogic.Plan)
+		} else if file.File.IsHardLinked() {
+			widgets = selectFilesTableRow(applogic.theme, file, fmt.Sprintf("%d links", file.File.NumLinks), filename, applogic.SizeMetric, &applogic.Columns, !applogic.Plan)
 		} else {
-			widgets = selectFilesTableRow(applogic.theme, file, "-", filepath.Join(path, file.File.Name))
+			widgets = selectFilesTableRow(applogic.theme, file, "-", filename, applogic.SizeMetric, &applogic.Columns, !applogic.Plan)
 		}
 		widgets = append(spacers, widgets...)
 		return layout.Flex{Alignment: layout.Middle}.Layout(gtx, widgets...)
 	})
 }
 
-func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickable, copy2clipboard *widget.Clickable, deletebutton *widget.Clickable, filedeletelist *widget.List) D {
+func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickable, copy2clipboard *widget.Clickable, deletebutton *widget.Clickable, deletemode *widget.Enum, dryrun *widget.Bool, filedeletelist *widget.List) D {
 
 	margins := layout.Inset{
 		Top:    unit.Dp(15),
@@ -442,15 +768,45 @@ func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickab
 		Left:   unit.Dp(15),
 	}
 
-	var tot_files, tot_size int64 = 0, 0
-	for _, file := range applogic.Selfiles {
-		if file.IsDir {
-			tot_files += file.NumChildren
-		} else {
-			tot_files++
+	// A plan made for other options is not valid anymore
+	if deletemode.Changed() || dryrun.Changed() {
+		applogic.DeletionPlan = nil
+	}
+
+	totals := applogic.Selection.Totals(applogic.SizeMetric)
+	tot_files, tot_size, freed_size := totals.NumFiles, totals.Size, totals.Freed
+	var totaltext string = "Total freed"
+	var plannednote string
+	if applogic.DeletionPlan != nil {
+		tot_files, tot_size, freed_size = applogic.DeletionPlan.NumFiles, applogic.DeletionPlan.Size, applogic.DeletionPlan.Freed
+		totaltext = "Total freed (dry run)"
+		plannednote = "Dry run: nothing has been deleted"
+		if applogic.DeletionPlan.Problems > 0 {
+			plannednote = fmt.Sprintf("Dry run: nothing has been deleted, %d of the selected files would not be deleted", applogic.DeletionPlan.Problems)
 		}
-		tot_size += file.Size
+	}
+	var hardlinksnote string
+	if freed_size < tot_size {
+		hardlinksnote = fmt.Sprintf("%s are hard links to files kept outside the selection and will not be freed", humanize.Bytes(uint64(tot_size-freed_size)))
+	}
+
+	var deletebuttontext string = SelectedDeleteMode(deletemode).String()
+	if dryrun.Value {
+		deletebuttontext = "Dry Run"
+	}
 
+	// Imported files are not on this computer, the selection is only a plan
+	var plannote string
+	if applogic.Plan {
+		deletebuttontext = "Delete (disabled)"
+		plannote = "Imported scan: copy the paths to delete them on the computer where they were scanned"
+	} else if len(applogic.Protected) > 0 && !dryrun.Value {
+		deletebuttontext = "Delete (refused)"
+		plannote = fmt.Sprintf("Cannot delete the selection: %s", applogic.Protected[0])
+		if len(applogic.Protected) > 1 {
+			plannote += fmt.Sprintf(" and %d more protected paths", len(applogic.Protected)-1)
+		}
+		plannote += ". Go back and unselect them"
 	}
 
 	return layout.Flex{
@@ -462,15 +818,43 @@ func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickab
 			layout.Spacer{Height: unit.Dp(25)}.Layout,
 		),
 		layout.Rigid(func(gtx C) D {
-			return deleteFilesTableRow(gtx, applogic.theme, "Path", "Num Children", "Size")
+			return deleteFilesTableRow(gtx, applogic.theme, "Path", "Num Children", applogic.SizeMetric.String())
 		}),
-		// Show selected files
+		// Show selected files, or what would happen to them after a dry run
 		layout.Flexed(1, func(gtx C) D {
+			if applogic.DeletionPlan != nil {
+				return applogic.plannedFiles(gtx, filedeletelist)
+			}
 			return applogic.selectedFiles(gtx, filedeletelist)
 		}),
 		// Show total
 		layout.Rigid(func(gtx C) D {
-			return deleteFilesTableRow(gtx, applogic.theme, "Total", humanize.Comma(tot_files), humanize.Bytes(uint64(tot_size)))
+			return deleteFilesTableRow(gtx, applogic.theme, totaltext, humanize.Comma(tot_files), humanize.Bytes(uint64(freed_size)))
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.Body2(applogic.theme, plannednote).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.Body2(applogic.theme, hardlinksnote).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.Body2(applogic.theme, plannote).Layout(gtx)
+		}),
+		// Choose how to delete the files, only the trash and the quarantine can be undone
+		layout.Rigid(func(gtx C) D {
+			// Only the modes of this OS are shown, there is no trash on some of them
+			modes := []layout.FlexChild{
+				layout.Rigid(func(gtx C) D {
+					return material.Body1(applogic.theme, "Delete by:").Layout(gtx)
+				}),
+			}
+			for _, mode := range cleanup.Modes {
+				modes = append(modes, deleteModeRadio(applogic.theme, deletemode, mode))
+			}
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx, modes...)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.CheckBox(applogic.theme, dryrun, "Dry run: check what would be deleted without deleting anything").Layout(gtx)
 		}),
 		// Show control buttons
 		layout.Rigid(func(gtx C) D {
@@ -493,7 +877,7 @@ func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickab
 				// Show delete button
 				layout.Flexed(1, func(gtx C) D {
 					return margins.Layout(gtx, func(gtx C) D {
-						return material.Button(applogic.theme, deletebutton, "Delete").Layout(gtx)
+						return material.Button(applogic.theme, deletebutton, deletebuttontext).Layout(gtx)
 					})
 				}),
 			)
@@ -502,17 +886,17 @@ func (applogic *AppLogic) ShowDeletingPage(gtx C, comebackbutton *widget.Clickab
 }
 
 func (applogic *AppLogic) selectedFiles(gtx C, filedeletelist *widget.List) D {
-	return filedeletelist.List.Layout(gtx, len(applogic.Selfiles), func(gtx C, index int) D {
-		var selfile *files.File = applogic.Selfiles[index]
+	return filedeletelist.List.Layout(gtx, applogic.Selection.Len(), func(gtx C, index int) D {
+		var selfile *files.File = applogic.Selection.Files[index]
 		var num_children, fullpath string
 		if selfile.IsDir {
-			fullpath = fmt.Sprintf("%s/", selfile.FullPath)
+			fullpath = fmt.Sprintf("%s/", selfile.Path())
 			num_children = humanize.Comma(selfile.NumChildren)
 		} else {
 			num_children = "-"
-			fullpath = selfile.FullPath
+			fullpath = selfile.Path()
 		}
 
-		return deleteFilesTableRow(gtx, applogic.theme, fullpath, num_children, humanize.Bytes(uint64(selfile.Size)))
+		return deleteFilesTableRow(gtx, applogic.theme, fullpath, num_children, humanize.Bytes(uint64(selfile.SizeBy(applogic.SizeMetric))))
 	})
 }
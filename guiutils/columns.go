@@ -0,0 +1,136 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/files"
+	"os/user"
+	"strconv"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// Optional columns of the selection table, each one is shown when its checkbox is selected
+type Columns struct {
+	ModTime    widget.Bool // Newest modification, for folders the newest of their content
+	AccessTime widget.Bool // Newest access, for folders the newest of their content
+	Owner      widget.Bool // User and group owning the file
+	Mode       widget.Bool // Permission bits
+
+	users  map[uint32]string // Cache of user names, looking them up is slow
+	groups map[uint32]string // Cache of group names
+}
+
+const timeColumnFormat = "2006-01-02"
+
+// Width of every optional column so the header and the rows are aligned
+var columnWidth = unit.Dp(100)
+
+// Row of checkboxes to choose which columns to show
+func (columns *Columns) checkboxes(th *material.Theme) layout.FlexChild {
+	return layout.Rigid(func(gtx C) D {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx C) D {
+				return material.Body1(th, "Columns:").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(th, &columns.ModTime, "Modified").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(th, &columns.AccessTime, "Accessed").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(th, &columns.Owner, "Owner").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.CheckBox(th, &columns.Mode, "Mode").Layout(gtx)
+			}),
+		)
+	})
+}
+
+// Titles of the columns being shown
+func (columns *Columns) header(th *material.Theme) []layout.FlexChild {
+	return columns.cells(th, "Modified", "Accessed", "Owner", "Mode")
+}
+
+// Values of the columns being shown for a file
+func (columns *Columns) row(th *material.Theme, file *files.File) []layout.FlexChild {
+	var owner string
+	if columns.Owner.Value {
+		owner = fmt.Sprintf("%s:%s", columns.userName(file.Uid), columns.groupName(file.Gid))
+	}
+	return columns.cells(th,
+		formatColumnTime(file.NewestModTime.Time()),
+		formatColumnTime(file.NewestAccessTime.Time()),
+		owner,
+		file.Mode.Perm().String(),
+	)
+}
+
+func (columns *Columns) cells(th *material.Theme, modtime string, accesstime string, owner string, mode string) []layout.FlexChild {
+	var cells []layout.FlexChild
+	for _, column := range []struct {
+		shown bool
+		text  string
+	}{
+		{columns.ModTime.Value, modtime},
+		{columns.AccessTime.Value, accesstime},
+		{columns.Owner.Value, owner},
+		{columns.Mode.Value, mode},
+	} {
+		if !column.shown {
+			continue
+		}
+		text := column.text
+		cells = append(cells,
+			layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
+			layout.Rigid(func(gtx C) D {
+				gtx.Constraints.Min.X = gtx.Dp(columnWidth)
+				gtx.Constraints.Max.X = gtx.Dp(columnWidth)
+				return material.Body1(th, text).Layout(gtx)
+			}),
+		)
+	}
+	return cells
+}
+
+func (columns *Columns) userName(uid uint32) string {
+	if columns.users == nil {
+		columns.users = map[uint32]string{}
+	}
+	name, ok := columns.users[uid]
+	if !ok {
+		name = strconv.FormatUint(uint64(uid), 10)
+		if usr, err := user.LookupId(name); err == nil {
+			name = usr.Username
+		}
+		columns.users[uid] = name
+	}
+	return name
+}
+
+func (columns *Columns) groupName(gid uint32) string {
+	if columns.groups == nil {
+		columns.groups = map[uint32]string{}
+	}
+	name, ok := columns.groups[gid]
+	if !ok {
+		name = strconv.FormatUint(uint64(gid), 10)
+		if group, err := user.LookupGroupId(name); err == nil {
+			name = group.Name
+		}
+		columns.groups[gid] = name
+	}
+	return name
+}
+
+func formatColumnTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(timeColumnFormat)
+}
@@ -0,0 +1,168 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/cleanup"
+	"time"
+
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/dustin/go-humanize"
+)
+
+// Deletes the selected files the way mode says, removing them from the tree and from
+// the selection. Deleting big folders takes a while, so it is done in the background
+// showing the deleting page, and then what happened to each file is shown. The
+// folders containing them are shown with their new sizes
+func (applogic *AppLogic) DeleteSelected(win *app.Window, mode cleanup.Mode) {
+
+	applogic.Appstate = DeletingS
+	go func() {
+		applogic.DeletionReport = cleanup.Delete(applogic.Files, &applogic.Selection, mode, applogic.Guard)
+		applogic.refreshFiles2Show()
+		applogic.Appstate = DelResultsS
+		win.Invalidate()
+	}()
+}
+
+// Tries again to delete the files that could not be deleted, which are still selected
+func (applogic *AppLogic) RetryFailed(win *app.Window) {
+
+	if applogic.DeletionReport == nil || applogic.DeletionReport.Failed == 0 {
+		return
+	}
+	applogic.DeleteSelected(win, applogic.DeletionReport.Mode)
+}
+
+// Shows that the selected files are being deleted
+func (applogic *AppLogic) ShowDeletingProgress(gtx C) D {
+
+	margins := layout.Inset{
+		Top:    unit.Dp(25),
+		Bottom: unit.Dp(25),
+		Right:  unit.Dp(35),
+		Left:   unit.Dp(35),
+	}
+
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Middle,
+		Spacing:   layout.SpaceEnd,
+	}.Layout(gtx,
+		// Space on the top of the window
+		layout.Rigid(
+			layout.Spacer{Height: unit.Dp(25)}.Layout,
+		),
+		showGocleasyLogo(gtx, margins),
+		layout.Rigid(func(gtx C) D {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					return material.Body1(applogic.theme, fmt.Sprintf("Deleting %d selected files...", applogic.Selection.Len())).Layout(gtx)
+				}),
+				layout.Rigid(
+					layout.Spacer{Width: unit.Dp(25)}.Layout,
+				),
+				layout.Rigid(func(gtx C) D {
+					return layout.Center.Layout(gtx, func(gtx C) D {
+						return material.Loader(applogic.theme).Layout(gtx)
+					})
+				}))
+		}),
+	)
+}
+
+// Summary of the last deletion shown in the selection page
+func (applogic *AppLogic) deletionSummary() string {
+
+	report := applogic.DeletionReport
+	text := fmt.Sprintf("Deleted %s files and %s", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)))
+	switch report.Mode {
+	case cleanup.MoveToTrashMode:
+		text = fmt.Sprintf("Moved %s files and %s to the trash, empty it to free the space", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)))
+	case cleanup.QuarantineMode:
+		text = fmt.Sprintf("Moved %s files and %s to the quarantine, they can be restored until %s", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)), time.Now().Add(applogic.QuarantineRetention).Format("2006-01-02"))
+	}
+	if report.Gone > 0 {
+		text += fmt.Sprintf(", %d were already gone", report.Gone)
+	}
+	if report.Failed > 0 {
+		text += fmt.Sprintf(", %d could not be deleted", report.Failed)
+	}
+	return text
+}
+
+// Shows what happened to every file in the last deletion, with the reason of the
+// ones that could not be deleted
+func (applogic *AppLogic) ShowDeletionResults(gtx C, backbutton *widget.Clickable, retrybutton *widget.Clickable, resultlist *widget.List) D {
+
+	margins := layout.Inset{
+		Top:    unit.Dp(15),
+		Bottom: unit.Dp(15),
+		Right:  unit.Dp(15),
+		Left:   unit.Dp(15),
+	}
+
+	report := applogic.DeletionReport
+	var done string = "Deleted"
+	switch report.Mode {
+	case cleanup.MoveToTrashMode:
+		done = "Moved to the trash"
+	case cleanup.QuarantineMode:
+		done = "Quarantined"
+	}
+
+	var widgets []layout.FlexChild = []layout.FlexChild{
+		// Space on the top of the window
+		layout.Rigid(
+			layout.Spacer{Height: unit.Dp(25)}.Layout,
+		),
+		layout.Rigid(func(gtx C) D {
+			return material.H6(applogic.theme, applogic.deletionSummary()).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return deleteFilesTableRow(gtx, applogic.theme, "Path", "Result", "Measured size")
+		}),
+		layout.Flexed(1, func(gtx C) D {
+			return resultlist.List.Layout(gtx, len(report.Results), func(gtx C, index int) D {
+				result := report.Results[index]
+				var status string = done
+				if result.Err != nil {
+					status = fmt.Sprintf("Failed: %s", result.Err)
+				} else if result.Gone {
+					status = "Already gone"
+				}
+				return deleteFilesTableRow(gtx, applogic.theme, result.Path, status, humanize.Bytes(uint64(result.Bytes)))
+			})
+		}),
+	}
+
+	// Only show the retry button if something failed
+	var buttons []layout.FlexChild = []layout.FlexChild{
+		layout.Flexed(1, func(gtx C) D {
+			return margins.Layout(gtx, func(gtx C) D {
+				return material.Button(applogic.theme, backbutton, "Back").Layout(gtx)
+			})
+		}),
+	}
+	if report.Failed > 0 {
+		buttons = append(buttons, layout.Flexed(1, func(gtx C) D {
+			return margins.Layout(gtx, func(gtx C) D {
+				return material.Button(applogic.theme, retrybutton, "Retry Failed").Layout(gtx)
+			})
+		}))
+	}
+	widgets = append(widgets, layout.Rigid(func(gtx C) D {
+		return layout.Flex{
+			Alignment: layout.Middle,
+			Axis:      layout.Horizontal,
+		}.Layout(gtx, buttons...)
+	}))
+
+	return layout.Flex{
+		Alignment: layout.Middle,
+		Axis:      layout.Vertical,
+	}.Layout(gtx, widgets...)
+}
@@ -0,0 +1,151 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/snapshot"
+	"log"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/dustin/go-humanize"
+)
+
+// DeltaShow is a change shown in the diff page, like FileShow for files
+type DeltaShow struct {
+	Delta        *files.Delta // Points to a change
+	Level        int          // Depth of the change in the tree
+	ActionButton widget.Bool  // Indicate if the folder has to be opened/closed
+}
+
+// Compares the files being shown with the newest snapshot of the same path saved
+// before them, and shows what changed
+func (applogic *AppLogic) CompareWithPrevious() {
+
+	var previous *snapshot.Snapshot
+	dir, err := snapshot.DefaultDir()
+	if err == nil {
+		var path string
+		path, err = snapshot.Previous(dir, applogic.Files.Name, applogic.ScanStarted)
+		if err == nil {
+			previous, err = snapshot.Load(path)
+		}
+	}
+	if err != nil {
+		log.Println(err)
+		applogic.SnapshotMessage = fmt.Sprintf("Could not compare: %s", err)
+		return
+	}
+
+	applogic.Delta = files.Diff(previous.Root, applogic.Files, applogic.SizeMetric)
+	applogic.DeltaSince = previous.Time
+	applogic.Deltas2Show = nil
+	applogic.appendDeltas2Show(applogic.Delta.Children, 0, map[*files.Delta]*DeltaShow{})
+	applogic.Appstate = DiffS
+}
+
+// Adds the changes to Deltas2Show, with the content of the folders that were opened
+func (applogic *AppLogic) appendDeltas2Show(deltas []*files.Delta, level int, shown map[*files.Delta]*DeltaShow) {
+
+	for _, delta := range deltas {
+		row, ok := shown[delta]
+		if !ok {
+			row = &DeltaShow{Delta: delta, Level: level}
+		}
+		applogic.Deltas2Show = append(applogic.Deltas2Show, row)
+		if row.ActionButton.Value {
+			applogic.appendDeltas2Show(delta.Children, level+1, shown)
+		}
+	}
+}
+
+// Formats a size change with its sign
+func formatGrowth(growth int64) string {
+	if growth < 0 {
+		return "-" + humanize.Bytes(uint64(-growth))
+	}
+	return "+" + humanize.Bytes(uint64(growth))
+}
+
+// Shows the changes since the previous snapshot, the ones that grew most first
+func (applogic *AppLogic) ShowDiff(gtx C, backbutton *widget.Clickable, difflist *widget.List) D {
+
+	// Rebuild the rows when a folder is opened or closed
+	var changed bool
+	for _, row := range applogic.Deltas2Show {
+		if row.ActionButton.Changed() {
+			changed = true
+		}
+	}
+	if changed {
+		shown := make(map[*files.Delta]*DeltaShow, len(applogic.Deltas2Show))
+		for _, row := range applogic.Deltas2Show {
+			shown[row.Delta] = row
+		}
+		applogic.Deltas2Show = nil
+		applogic.appendDeltas2Show(applogic.Delta.Children, 0, shown)
+	}
+
+	title := fmt.Sprintf("%s since %s (%s)", formatGrowth(applogic.Delta.Growth()), applogic.DeltaSince.Format("2006-01-02 15:04"), humanize.Time(applogic.DeltaSince))
+
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(
+			layout.Spacer{Height: unit.Dp(25)}.Layout,
+		),
+		layout.Rigid(func(gtx C) D {
+			return material.H6(applogic.theme, title).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return deleteFilesTableRow(gtx, applogic.theme, "Path", "Change", applogic.SizeMetric.String())
+		}),
+		layout.Flexed(1, func(gtx C) D {
+			return difflist.List.Layout(gtx, len(applogic.Deltas2Show), func(gtx C, index int) D {
+				return applogic.diffRow(gtx, applogic.Deltas2Show[index])
+			})
+		}),
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(25)).Layout(gtx, func(gtx C) D {
+				return material.Button(applogic.theme, backbutton, "Back").Layout(gtx)
+			})
+		}),
+	)
+}
+
+func (applogic *AppLogic) diffRow(gtx C, row *DeltaShow) D {
+
+	delta := row.Delta
+	name := delta.Name
+	if delta.IsDir {
+		name = fmt.Sprintf("%s/", name)
+	}
+	if delta.Kind == files.Added || delta.Kind == files.Removed {
+		name = fmt.Sprintf("%s (%s)", name, delta.Kind)
+	}
+
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(layout.Spacer{Width: unit.Dp(row.Level*25 + 25)}.Layout),
+		// Open the folder to see what changed inside
+		layout.Rigid(func(gtx C) D {
+			if len(delta.Children) == 0 {
+				return material.Body1(applogic.theme, name).Layout(gtx)
+			}
+			return material.CheckBox(applogic.theme, &row.ActionButton, name).Layout(gtx)
+		}),
+		layout.Flexed(1, layout.Spacer{}.Layout),
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(applogic.theme, formatGrowth(delta.Growth())).Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(applogic.theme, humanize.Bytes(uint64(delta.NewSize))).Layout(gtx)
+		}),
+	)
+}
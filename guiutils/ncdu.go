@@ -0,0 +1,52 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/ncdu"
+	"gocleasy/snapshot"
+	"log"
+	"path/filepath"
+)
+
+// Shows the files of the ncdu export at path to plan what to delete. The files are
+// on the computer where ncdu was run, so they cannot be deleted or scanned again
+func (applogic *AppLogic) ImportNcdu(path string) bool {
+
+	export, err := ncdu.ImportFile(path)
+	if err != nil {
+		log.Println(err)
+		applogic.SnapshotMessage = fmt.Sprintf("Could not import ncdu export: %s", err)
+		return false
+	}
+
+	applogic.Files = export.Root
+	applogic.ScanErrors = nil
+	applogic.Files2Show = nil
+	applogic.Selection.Clear()
+	applogic.DeletionReport = nil
+	applogic.Plan = true
+	applogic.ScanStarted = export.Time()
+	applogic.SnapshotMessage = fmt.Sprintf("Plan for %s, scanned by %s on %s", export.Root.Name, export.Metadata.Progname, export.Time().Format("2006-01-02 15:04"))
+
+	files.SortDescBy(applogic.Files, applogic.SizeMetric)
+	applogic.FillFirstLayer2Show()
+	applogic.Appstate = SelFilesS
+	return true
+}
+
+// Writes the files being shown in the ncdu export format next to the snapshots
+func (applogic *AppLogic) ExportNcdu() {
+
+	dir, err := snapshot.DefaultDir()
+	path := filepath.Join(dir, applogic.ScanStarted.Format("20060102-150405")+".ncdu.json")
+	if err == nil {
+		err = ncdu.WriteFile(path, applogic.Files, applogic.ScanStarted)
+	}
+	if err != nil {
+		log.Println(err)
+		applogic.SnapshotMessage = fmt.Sprintf("Could not export for ncdu: %s", err)
+		return
+	}
+	applogic.SnapshotMessage = fmt.Sprintf("Exported for ncdu to %s", path)
+}
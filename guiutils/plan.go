@@ -0,0 +1,41 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/cleanup"
+
+	"gioui.org/widget"
+	"github.com/dustin/go-humanize"
+)
+
+// Works out what deleting the selected files the way mode says would do, without
+// deleting them. The plan is shown in the deleting page instead of the selection
+func (applogic *AppLogic) PlanDeletion(mode cleanup.Mode) {
+
+	applogic.DeletionPlan = cleanup.NewPlan(&applogic.Selection, mode, applogic.SizeMetric, applogic.Guard)
+}
+
+// Shows what would happen to every selected file in the last plan
+func (applogic *AppLogic) plannedFiles(gtx C, filedeletelist *widget.List) D {
+	return filedeletelist.List.Layout(gtx, len(applogic.DeletionPlan.Entries), func(gtx C, index int) D {
+		entry := applogic.DeletionPlan.Entries[index]
+		var fullpath string = entry.Path
+		if entry.File.IsDir {
+			fullpath += "/"
+		}
+
+		var status string
+		switch {
+		case entry.Inside != nil:
+			status = fmt.Sprintf("Deleted with %s", entry.Inside.Name)
+		case entry.Problem != nil:
+			status = fmt.Sprintf("Would fail: %s", entry.Problem)
+		case entry.File.IsDir:
+			status = humanize.Comma(entry.NumFiles)
+		default:
+			status = "-"
+		}
+
+		return deleteFilesTableRow(gtx, applogic.theme, fullpath, status, humanize.Bytes(uint64(entry.Size)))
+	})
+}
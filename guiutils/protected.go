@@ -0,0 +1,18 @@
+package guiutils
+
+import (
+	"gocleasy/protect"
+)
+
+// Checks if the selection touches protected paths before showing the deleting page,
+// which refuses to delete it until they are unselected
+func (applogic *AppLogic) CheckProtected() {
+
+	applogic.Guard = protect.Default(applogic.Files.Path())
+	applogic.Protected = nil
+	for _, file := range applogic.Selection.Files {
+		if err := applogic.Guard.Check(file); err != nil {
+			applogic.Protected = append(applogic.Protected, err)
+		}
+	}
+}
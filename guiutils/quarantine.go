@@ -0,0 +1,170 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/quarantine"
+	"log"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/dustin/go-humanize"
+)
+
+// QuarantineShow is a batch of quarantined files in the quarantine page
+type QuarantineShow struct {
+	Batch         *quarantine.Batch
+	RestoreButton widget.Clickable
+	PurgeButton   widget.Clickable
+}
+
+// Purges the quarantined files kept for longer than the retention period and lists
+// the rest in the quarantine page
+func (applogic *AppLogic) OpenQuarantine() {
+
+	applogic.QuarantineMessage = ""
+	applogic.Quarantine = nil
+	dir, err := quarantine.DefaultDir()
+	if err != nil {
+		log.Println(err)
+		applogic.QuarantineMessage = fmt.Sprintf("Could not open the quarantine: %s", err)
+		return
+	}
+	purged, err := quarantine.PurgeExpired(dir, applogic.QuarantineRetention, time.Now())
+	if err != nil {
+		log.Println(err)
+	}
+	if len(purged) > 0 {
+		applogic.QuarantineMessage = fmt.Sprintf("%d batches kept for more than %s were purged", len(purged), formatRetention(applogic.QuarantineRetention))
+	}
+
+	batches, err := quarantine.List(dir)
+	if err != nil {
+		log.Println(err)
+		applogic.QuarantineMessage = fmt.Sprintf("Could not open the quarantine: %s", err)
+		return
+	}
+	for _, batch := range batches {
+		applogic.Quarantine = append(applogic.Quarantine, &QuarantineShow{Batch: batch})
+	}
+}
+
+// Restores or purges the batches whose buttons have been clicked
+func (applogic *AppLogic) updateQuarantine() {
+
+	var kept []*QuarantineShow
+	for _, row := range applogic.Quarantine {
+		batch := row.Batch
+		switch {
+		case row.RestoreButton.Clicked():
+			if err := batch.Restore(); err != nil {
+				log.Println(err)
+				applogic.QuarantineMessage = fmt.Sprintf("Could not restore all the files of %s: %s", batch.Time.Format("2006-01-02 15:04"), err)
+			} else {
+				applogic.QuarantineMessage = fmt.Sprintf("Restored the files deleted on %s, scan again to see them", batch.Time.Format("2006-01-02 15:04"))
+			}
+			// Files that could not be restored are still in the batch
+			if len(batch.Items) == 0 {
+				continue
+			}
+		case row.PurgeButton.Clicked():
+			if err := batch.Purge(); err != nil {
+				log.Println(err)
+				applogic.QuarantineMessage = fmt.Sprintf("Could not purge %s: %s", batch.Time.Format("2006-01-02 15:04"), err)
+			} else {
+				applogic.QuarantineMessage = fmt.Sprintf("Freed %s deleted on %s", humanize.Bytes(uint64(batch.Size())), batch.Time.Format("2006-01-02 15:04"))
+				continue
+			}
+		}
+		kept = append(kept, row)
+	}
+	applogic.Quarantine = kept
+}
+
+// Shows the batches of files in quarantine with their size and date, the newest first
+func (applogic *AppLogic) ShowQuarantine(gtx C, backbutton *widget.Clickable, quarantinelist *widget.List) D {
+
+	applogic.updateQuarantine()
+
+	var total int64
+	for _, row := range applogic.Quarantine {
+		total += row.Batch.Size()
+	}
+	title := fmt.Sprintf("Quarantine: %s in %d batches, kept for %s", humanize.Bytes(uint64(total)), len(applogic.Quarantine), formatRetention(applogic.QuarantineRetention))
+
+	return layout.Flex{
+		Axis:      layout.Vertical,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(
+			layout.Spacer{Height: unit.Dp(25)}.Layout,
+		),
+		layout.Rigid(func(gtx C) D {
+			return material.H6(applogic.theme, title).Layout(gtx)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(applogic.theme, applogic.QuarantineMessage).Layout(gtx)
+		}),
+		layout.Flexed(1, func(gtx C) D {
+			return quarantinelist.List.Layout(gtx, len(applogic.Quarantine), func(gtx C, index int) D {
+				return applogic.quarantineRow(gtx, applogic.Quarantine[index])
+			})
+		}),
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(25)).Layout(gtx, func(gtx C) D {
+				return material.Button(applogic.theme, backbutton, "Back").Layout(gtx)
+			})
+		}),
+	)
+}
+
+func (applogic *AppLogic) quarantineRow(gtx C, row *QuarantineShow) D {
+
+	batch := row.Batch
+	var paths string
+	if len(batch.Items) > 0 {
+		paths = batch.Items[0].Path
+	}
+	if len(batch.Items) > 1 {
+		paths = fmt.Sprintf("%s and %d more", paths, len(batch.Items)-1)
+	}
+	details := fmt.Sprintf("%s files, %s, purged on %s", humanize.Comma(batch.NumFiles()), humanize.Bytes(uint64(batch.Size())), batch.Expires(applogic.QuarantineRetention).Format("2006-01-02"))
+
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(gtx,
+		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
+		layout.Rigid(func(gtx C) D {
+			return material.Body1(applogic.theme, batch.Time.Format("2006-01-02 15:04")).Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(25)}.Layout),
+		layout.Flexed(1, func(gtx C) D {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx C) D {
+					return material.Body1(applogic.theme, paths).Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return material.Body2(applogic.theme, details).Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(5)).Layout(gtx, material.Button(applogic.theme, &row.RestoreButton, "Restore").Layout)
+		}),
+		layout.Rigid(func(gtx C) D {
+			return layout.UniformInset(unit.Dp(5)).Layout(gtx, material.Button(applogic.theme, &row.PurgeButton, "Purge").Layout)
+		}),
+		layout.Rigid(layout.Spacer{Width: unit.Dp(20)}.Layout),
+	)
+}
+
+// Formats the retention period in days, or in hours when it is shorter than a day
+func formatRetention(retention time.Duration) string {
+	if retention < 24*time.Hour {
+		return fmt.Sprintf("%.0f hours", retention.Hours())
+	}
+	return fmt.Sprintf("%.0f days", retention.Hours()/24)
+}
@@ -0,0 +1,70 @@
+package guiutils
+
+import (
+	"context"
+	"gocleasy/files"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScanConfig stores how the files were scanned
+type ScanConfig struct {
+	ReadDir files.ReadDir            // Function used to list the folders
+	Ignore  files.ShouldIgnoreFolder // Function used to ignore folders
+	Options files.WalkOptions        // Options of the walker
+}
+
+// Scans the folder again and updates the tree, the selected files and the errors found.
+// The folder stays opened showing its new content. Progress events are sent to progress,
+// which is closed once the tree is updated, and the scan stops when ctx is cancelled
+func (applogic *AppLogic) RefreshFolder(ctx context.Context, folder *files.File, progress chan<- files.Progress) {
+
+	defer close(progress)
+
+	// The walker closes its channel when it finishes reading, before the tree is updated
+	walkprogress, forwarded := make(chan files.Progress), make(chan struct{})
+	go func() {
+		for event := range walkprogress {
+			progress <- event
+		}
+		close(forwarded)
+	}()
+	result, err := applogic.Files.Rescan(ctx, folder, applogic.ScanConfig.ReadDir, applogic.ScanConfig.Ignore, walkprogress, applogic.ScanConfig.Options)
+	if err != nil {
+		close(walkprogress)
+		<-forwarded
+		log.Println(err)
+		return
+	}
+	<-forwarded
+
+	// Files selected inside the folder are not part of the tree anymore
+	applogic.Selection.UnselectInside(folder)
+
+	// Replace the errors found inside the folder by the new ones
+	var scanerrors []*files.ScanError
+	var folderpath string = folder.Path()
+	for _, scanerror := range applogic.ScanErrors {
+		if scanerror.Path != folderpath && !isPathInside(scanerror.Path, folderpath) {
+			scanerrors = append(scanerrors, scanerror)
+		}
+	}
+	scanerrors = append(scanerrors, result.Errors...)
+	sort.Slice(scanerrors, func(i, j int) bool {
+		return scanerrors[i].Path < scanerrors[j].Path
+	})
+	applogic.ScanErrors = scanerrors
+
+	files.SortDescBy(applogic.Files, applogic.SizeMetric)
+	applogic.refreshFiles2Show()
+}
+
+// Checks if path is inside the folder dir
+func isPathInside(path string, dir string) bool {
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
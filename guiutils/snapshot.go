@@ -0,0 +1,67 @@
+package guiutils
+
+import (
+	"fmt"
+	"gocleasy/files"
+	"gocleasy/snapshot"
+	"log"
+)
+
+// Loads the snapshot saved at path, or the latest one saved by gocleasy if path is
+// empty, and shows its files as if they had just been scanned. Returns false if
+// it could not be loaded
+func (applogic *AppLogic) LoadSnapshot(path string) bool {
+
+	var err error
+	if path == "" {
+		var dir string
+		dir, err = snapshot.DefaultDir()
+		if err == nil {
+			path, err = snapshot.Latest(dir)
+		}
+	}
+	var loaded *snapshot.Snapshot
+	if err == nil {
+		loaded, err = snapshot.Load(path)
+	}
+	if err != nil {
+		log.Println(err)
+		applogic.SnapshotMessage = fmt.Sprintf("Could not load snapshot: %s", err)
+		return false
+	}
+
+	applogic.Files = loaded.Root
+	applogic.ScanErrors = loaded.Errors
+	applogic.Files2Show = nil
+	applogic.Selection.Clear()
+	applogic.DeletionReport = nil
+	applogic.Plan = false
+	applogic.ScanStarted = loaded.Time
+	applogic.ScanConfig.Options = loaded.Options
+	applogic.SnapshotMessage = fmt.Sprintf("Scan of %s from %s", loaded.Path(), loaded.Time.Format("2006-01-02 15:04"))
+
+	files.SortDescBy(applogic.Files, applogic.SizeMetric)
+	applogic.FillFirstLayer2Show()
+	applogic.Appstate = SelFilesS
+	return true
+}
+
+// Saves the files being shown in the snapshots folder of gocleasy
+func (applogic *AppLogic) SaveSnapshot() {
+
+	path, err := snapshot.DefaultPath(applogic.ScanStarted)
+	if err == nil {
+		err = snapshot.Save(path, &snapshot.Snapshot{
+			Root:    applogic.Files,
+			Errors:  applogic.ScanErrors,
+			Time:    applogic.ScanStarted,
+			Options: applogic.ScanConfig.Options,
+		})
+	}
+	if err != nil {
+		log.Println(err)
+		applogic.SnapshotMessage = fmt.Sprintf("Could not save snapshot: %s", err)
+		return
+	}
+	applogic.SnapshotMessage = fmt.Sprintf("Snapshot saved to %s", path)
+}
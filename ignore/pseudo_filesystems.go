@@ -0,0 +1,128 @@
+package ignore
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gocleasy/files"
+)
+
+// Filesystems that do not store files on disk, their sizes are meaningless
+var pseudoFilesystems = map[string]struct{}{
+	"autofs":      {},
+	"binfmt_misc": {},
+	"bpf":         {},
+	"cgroup":      {},
+	"cgroup2":     {},
+	"configfs":    {},
+	"debugfs":     {},
+	"devpts":      {},
+	"devtmpfs":    {},
+	"efivarfs":    {},
+	"fusectl":     {},
+	"hugetlbfs":   {},
+	"mqueue":      {},
+	"nsfs":        {},
+	"proc":        {},
+	"pstore":      {},
+	"rpc_pipefs":  {},
+	"securityfs":  {},
+	"selinuxfs":   {},
+	"sysfs":       {},
+	"tracefs":     {},
+}
+
+// ReadPseudoFilesystems returns the mount points of pseudo filesystems like /proc or /sys.
+// They are read from /proc/self/mountinfo, so the list is empty on systems without it
+func ReadPseudoFilesystems() []string {
+	mountInfo, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read mount points because %s\n", err.Error())
+		}
+		return []string{}
+	}
+	defer mountInfo.Close()
+	return parseMountInfo(mountInfo)
+}
+
+// parseMountInfo extracts the mount points of pseudo filesystems from the
+// mountinfo format described in proc(5):
+// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseMountInfo(mountInfo io.Reader) []string {
+	mountPoints := []string{}
+	scanner := bufio.NewScanner(mountInfo)
+	for scanner.Scan() {
+		fields, fsFields, found := strings.Cut(scanner.Text(), " - ")
+		if !found {
+			continue
+		}
+		mountFields := strings.Fields(fields)
+		fsType := strings.Fields(fsFields)
+		if len(mountFields) < 5 || len(fsType) < 1 {
+			continue
+		}
+		if _, pseudo := pseudoFilesystems[fsType[0]]; pseudo {
+			mountPoints = append(mountPoints, unescapeMountPoint(mountFields[4]))
+		}
+	}
+	return mountPoints
+}
+
+// unescapeMountPoint decodes the octal escapes (\040 for a space) used in mountinfo
+func unescapeMountPoint(mountPoint string) string {
+	var result strings.Builder
+	for i := 0; i < len(mountPoint); i++ {
+		if mountPoint[i] == '\\' && i+3 < len(mountPoint) {
+			if value, err := strconv.ParseUint(mountPoint[i+1:i+4], 8, 8); err == nil {
+				result.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		result.WriteByte(mountPoint[i])
+	}
+	return result.String()
+}
+
+// IgnoreMountPoints ignores the given mount points, and therefore everything mounted under them
+func IgnoreMountPoints(mountPoints []string) files.ShouldIgnoreFolder {
+	ignoredFolders := map[string]struct{}{}
+	for _, mountPoint := range mountPoints {
+		ignoredFolders[filepath.Clean(mountPoint)] = struct{}{}
+	}
+	return func(absolutePath string) bool {
+		// The scanned path may be relative, like "." scanned from /
+		path, err := filepath.Abs(absolutePath)
+		if err != nil {
+			path = filepath.Clean(absolutePath)
+		}
+		_, ignored := ignoredFolders[path]
+		return ignored
+	}
+}
+
+// Any ignores a folder if any of the given functions ignores it
+func Any(ignoreFunctions ...files.ShouldIgnoreFolder) files.ShouldIgnoreFolder {
+	return func(absolutePath string) bool {
+		for _, shouldIgnore := range ignoreFunctions {
+			if shouldIgnore(absolutePath) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Default ignores the folders listed in the ignore file and the pseudo filesystems
+func Default() files.ShouldIgnoreFolder {
+	return Any(
+		IgnoreBasedOnIgnoreFile(ReadIgnoreFile()),
+		IgnoreMountPoints(ReadPseudoFilesystems()),
+	)
+}
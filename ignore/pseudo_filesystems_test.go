@@ -0,0 +1,44 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	mountInfo := `22 28 0:21 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+23 28 0:22 / /proc rw,nosuid,nodev,noexec,relatime shared:13 - proc proc rw
+28 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro
+30 23 0:27 / /proc/sys/fs/binfmt_misc rw,relatime shared:14 - autofs systemd-1 rw,fd=29
+40 28 8:2 / /mnt/my\040disk rw,relatime shared:20 - ext4 /dev/sdb1 rw
+41 28 0:40 / /mnt/weird\040proc rw,relatime - proc proc rw
+`
+	mountPoints := parseMountInfo(strings.NewReader(mountInfo))
+	assert.Equal(t, []string{"/sys", "/proc", "/proc/sys/fs/binfmt_misc", "/mnt/weird proc"}, mountPoints)
+
+	shouldIgnore := IgnoreMountPoints(mountPoints)
+	assert.True(t, shouldIgnore("/proc"))
+	assert.True(t, shouldIgnore("/sys/"))
+	assert.False(t, shouldIgnore("/mnt/my disk"))
+	assert.False(t, shouldIgnore("/home/proc"))
+}
+
+func TestIgnoreRelativeMountPoints(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	shouldIgnore := IgnoreMountPoints([]string{filepath.Join(wd, "proc")})
+	assert.True(t, shouldIgnore("proc"), "relative paths should be matched from the working folder")
+	assert.True(t, shouldIgnore(filepath.Join(".", "proc", "..", "proc")))
+	assert.False(t, shouldIgnore("sys"))
+}
+
+func TestAny(t *testing.T) {
+	shouldIgnore := Any(IgnoreMountPoints([]string{"/proc"}), IgnoreBasedOnIgnoreFile([]string{"node_modules"}))
+	assert.True(t, shouldIgnore("/proc"))
+	assert.True(t, shouldIgnore("/home/project/node_modules"))
+	assert.False(t, shouldIgnore("/home/project"))
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"gocleasy/cleanup"
 	"gocleasy/files"
 	"gocleasy/guiutils"
 	"gocleasy/ignore"
+	"gocleasy/quarantine"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"runtime"
+	"time"
 
 	"gioui.org/app"
 	"gioui.org/io/system"
@@ -45,70 +49,6 @@ func calculateDirSize(basepath string) (int64, int64, error) {
 	return size, numchildren, err
 }
 
-func DeleteFiles(selected_files []*files.File) (int64, int64) {
-
-	var errslice []error
-	var err error
-	var numfiles, sizeliberated int64
-
-	// Loop over selected files and delete them
-	for _, file := range selected_files {
-		if file.IsDir {
-			numfiles += file.NumChildren
-		} else {
-			numfiles++
-		}
-		sizeliberated += file.Size
-		log.Print("WARNING: If you are testing, you may want to comment the following lines")
-		err = os.RemoveAll(file.FullPath)
-		if err != nil {
-			errslice = append(errslice, err)
-		}
-	}
-
-	for _, er1 := range errslice {
-		// If there is any error with any file/folder you can handle it here
-		log.Print(er1)
-	}
-
-	return numfiles, sizeliberated
-}
-
-func getRootPath() string {
-	switch runtime.GOOS {
-	case "windows":
-		return getWindowsRootPath()
-	case "darwin":
-		// macOS
-		return "/"
-	case "android", "linux":
-		// For Android apps you will need to request permission for reading from external folders.
-		// I was not able to perform that with gioui or golang, maybe you need to create a connector for JAVA
-		return "/"
-	case "ios":
-		// iOS apps are sandboxed too, so the root path will not be directly accessible
-		return getIOSRootPath()
-	default:
-		return "Unknown OS"
-	}
-}
-
-func getWindowsRootPath() string {
-	// On Windows, the root path is typically the drive where the OS is installed,
-	// so we need to get the current drive and concatenate it with the path separator.
-	return filepath.VolumeName(os.Getenv("SystemDrive")) + string(filepath.Separator)
-}
-
-func getIOSRootPath() string {
-	// In iOS, the application's root path is restricted, but you can use other directories like the Documents directory.
-	// This is just an example of how you could handle it, but it's not the actual root path.
-	documentsDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
-	return filepath.Join(documentsDir, "Documents")
-}
-
 // Deletes a string from a slice if exists
 func deleteStringFromSlice(str string, slice []string) []string {
 	// Find and remove the string from the slide
@@ -122,7 +62,7 @@ func deleteStringFromSlice(str string, slice []string) []string {
 }
 
 func printTree(file *files.File, indent string) {
-	fmt.Printf("%s%s\n", indent, file.FullPath)
+	fmt.Printf("%s%s\n", indent, file.Path())
 	for _, subFile := range file.Files {
 		printTree(subFile, indent+"\t")
 	}
@@ -157,15 +97,24 @@ func copyFilesInClipboard(selfiles []*files.File) {
 	var result string = ""
 
 	for _, file := range selfiles {
-		result += "\"" + file.FullPath + "\" "
+		result += "\"" + file.Path() + "\" "
 	}
 
 	clipboard.Write(clipboard.FmtText, []byte(result))
 }
 
-func Run(win *app.Window) error {
+// Creates how the files are scanned, also used to scan again folders
+func newScanConfig(options files.WalkOptions) guiutils.ScanConfig {
+	return guiutils.ScanConfig{ReadDir: ioutil.ReadDir, Ignore: ignore.Default(), Options: options}
+}
+
+// Run shows the window until it is closed. If snapshotpath is not empty the saved
+// scan is shown instead of the home page, and if ncdupath is not empty the ncdu export.
+// Quarantined files are purged after retention
+func Run(win *app.Window, snapshotpath string, ncdupath string, retention time.Duration) error {
 
 	var applogic *guiutils.AppLogic = guiutils.NewAppLogic()
+	applogic.QuarantineRetention = retention
 
 	// ops are the operations from the UI
 	var ops op.Ops
@@ -176,32 +125,72 @@ func Run(win *app.Window) error {
 	var comeBackButton widget.Clickable
 	var copy2clipboard widget.Clickable
 	var nextButton widget.Clickable
+	var newScanButton widget.Clickable
+	var loadSnapshotButton widget.Clickable
+	var saveSnapshotButton widget.Clickable
+	var importNcduButton widget.Clickable
+	var exportNcduButton widget.Clickable
+	var compareButton widget.Clickable
+	var diffBackButton widget.Clickable
+	var cancelScanButton widget.Clickable
 	var initialPathInput widget.Editor
+	var oneFileSystem widget.Bool
+	var symlinkPolicy widget.Enum = widget.Enum{Value: files.CountSymlinks.String()}
 	var filelist widget.List = widget.List{
 		List: layout.List{
 			Axis: layout.Vertical,
 		},
 	}
+	var showSkippedPaths widget.Bool
+	var showDiskUsage widget.Bool
+	var deleteMode widget.Enum = widget.Enum{Value: cleanup.DefaultMode.String()}
+	var dryRun widget.Bool
+	var resultsBackButton widget.Clickable
+	var retryButton widget.Clickable
+	var quarantineButton widget.Clickable
+	var quarantineBackButton widget.Clickable
+	var quarantinelist widget.List = widget.List{
+		List: layout.List{
+			Axis: layout.Vertical,
+		},
+	}
+	var resultlist widget.List = widget.List{
+		List: layout.List{
+			Axis: layout.Vertical,
+		},
+	}
+	var skippedlist widget.List = widget.List{
+		List: layout.List{
+			Axis: layout.Vertical,
+		},
+	}
 	var filedeletelist widget.List = widget.List{
 		List: layout.List{
 			Axis: layout.Vertical,
 		},
 	}
+	var difflist widget.List = widget.List{
+		List: layout.List{
+			Axis: layout.Vertical,
+		},
+	}
 
-	var scanfilesLoadingChann chan int = make(chan int) // Used to transmit how many files have been read
-	var numfilesdeleted int64 = 0
-	var sizeliberated int64 = 0
+	var cancelScan context.CancelFunc = func() {} // Used to stop the scan in progress
 
 	var initialpath string
 
-	var totalFilesReadShow int = 0 // Used to maintain a count of the files read
-
 	// Initialize clipboard so you can set the clipboard
 	err := clipboard.Init()
 	if err != nil {
 		panic(err)
 	}
 
+	if snapshotpath != "" && applogic.LoadSnapshot(snapshotpath) {
+		applogic.ScanConfig = newScanConfig(applogic.ScanConfig.Options)
+	} else if ncdupath != "" {
+		applogic.ImportNcdu(ncdupath)
+	}
+
 	// Listen for events in the window
 	for {
 		e := <-win.Events()
@@ -209,6 +198,7 @@ func Run(win *app.Window) error {
 		switch e := e.(type) {
 		// Window closed
 		case system.DestroyEvent:
+			cancelScan()
 			return e.Err
 
 		// Actions in the window apart from closing
@@ -224,10 +214,16 @@ func Run(win *app.Window) error {
 
 				// reset file directory
 				applogic.Files = nil
+				applogic.Files2Show = nil
+				applogic.Selection.Clear()
+				applogic.ScanErrors = nil
+				applogic.DeletionReport = nil
+				applogic.SnapshotMessage = ""
+				applogic.Plan = false
 
 				initialpath = initialPathInput.Text()
 				if initialpath == "" {
-					initialpath = getRootPath()
+					initialpath = files.RootPath()
 				}
 
 				// Test the introduced path, if not good, use the root path
@@ -238,22 +234,86 @@ func Run(win *app.Window) error {
 					// If there is no problem, continue
 					applogic.Appstate = guiutils.LoadingFilesS
 
-					go applogic.ReportProgress(win, &totalFilesReadShow, scanfilesLoadingChann)
+					var ctx context.Context
+					ctx, cancelScan = context.WithCancel(context.Background())
+					scanfilesLoadingChann := make(chan files.Progress) // Used to transmit how far the scan has gone
+					applogic.ScanProgress = files.Progress{}
+					applogic.ScanStarted = time.Now()
+					applogic.ScanEstimate = nil
+					applogic.ScanFraction, applogic.ScanETA = 0, 0
+					if estimate, ok := files.EstimateScan(initialpath); ok {
+						// The whole filesystem is going to be scanned so its statistics tell how far we are
+						applogic.ScanEstimate = &estimate
+					}
+
+					go applogic.ReportProgress(win, scanfilesLoadingChann)
 					go func() {
-						applogic.Files = files.WalkFolder(initialpath, ioutil.ReadDir, ignore.IgnoreBasedOnIgnoreFile(ignore.ReadIgnoreFile()), scanfilesLoadingChann)
+						config := newScanConfig(files.WalkOptions{
+							OneFileSystem: oneFileSystem.Value,
+							Symlinks:      guiutils.SelectedSymlinkPolicy(&symlinkPolicy),
+						})
+						applogic.ScanConfig = config
+						result := files.WalkFolderContext(ctx, initialpath, config.ReadDir, config.Ignore, scanfilesLoadingChann, config.Options)
+						applogic.Files = result.Root
+						applogic.ScanErrors = result.Errors
+						files.SortDescBy(applogic.Files, applogic.SizeMetric)
 						// Add first level of files to be shown
 						applogic.FillFirstLayer2Show()
 					}()
-					applogic.ShowLoadingPage(gtx, totalFilesReadShow)
+					applogic.ShowLoadingPage(gtx, &cancelScanButton)
 				}
 			}
 
+			// Show a saved scan, the one in the path introduced or the latest one
+			if loadSnapshotButton.Clicked() {
+				if applogic.LoadSnapshot(initialPathInput.Text()) {
+					applogic.ScanConfig = newScanConfig(applogic.ScanConfig.Options)
+				}
+			}
+
+			// Show the files of an ncdu export to plan what to delete
+			if importNcduButton.Clicked() {
+				applogic.ImportNcdu(initialPathInput.Text())
+			}
+
+			// Write the scan being shown to open it with ncdu
+			if exportNcduButton.Clicked() {
+				applogic.ExportNcdu()
+			}
+
+			// Show what changed since the previous snapshot of the same path
+			if compareButton.Clicked() {
+				applogic.CompareWithPrevious()
+			}
+
+			// Go back from the changes to selecting the files
+			if diffBackButton.Clicked() {
+				applogic.Appstate = guiutils.SelFilesS
+			}
+
+			// Save the scan being shown to browse it later without scanning again
+			if saveSnapshotButton.Clicked() {
+				applogic.SaveSnapshot()
+			}
+
+			// Stop the scan, the files read so far are shown
+			if cancelScanButton.Clicked() {
+				cancelScan()
+			}
+
 			// Go to confirm deleting the files
 			if nextButton.Clicked() {
 				// applogic.Selfiles = getSelectedFiles(applogic.Files.Files, &applogic.Selfiles)
+				applogic.DeletionPlan = nil
+				applogic.CheckProtected()
 				applogic.Appstate = guiutils.DelFilesS
 			}
 
+			// Go back to the home page to scan another path
+			if newScanButton.Clicked() {
+				applogic.Appstate = guiutils.HomeS
+			}
+
 			// Go back to selecting the files
 			if comeBackButton.Clicked() {
 				applogic.Appstate = guiutils.SelFilesS
@@ -261,12 +321,40 @@ func Run(win *app.Window) error {
 
 			// copy files in clipboard
 			if copy2clipboard.Clicked() {
-				copyFilesInClipboard(applogic.Selfiles)
+				copyFilesInClipboard(applogic.Selection.Files)
+			}
+
+			// Delete the files and show what happened to each of them, or show what would be deleted in a dry run
+			if deleteButton.Clicked() && !applogic.Plan {
+				var mode cleanup.Mode = guiutils.SelectedDeleteMode(&deleteMode)
+				if dryRun.Value {
+					// Only show what would happen, staying in the deleting page
+					applogic.PlanDeletion(mode)
+				} else if len(applogic.Protected) == 0 {
+					applogic.DeleteSelected(win, mode)
+					// Deleting for good has to be chosen again every time
+					if mode == cleanup.PermanentMode {
+						deleteMode.Value = cleanup.DefaultMode.String()
+					}
+				}
+			}
+
+			// Try again to delete the files that failed
+			if retryButton.Clicked() {
+				applogic.RetryFailed(win)
+			}
+
+			// Go back to the selection showing the number of files deleted and amount of memory freed
+			if resultsBackButton.Clicked() {
+				applogic.Appstate = guiutils.SelFilesS
 			}
 
-			// Delete the files show a message of number of files deleted and amount of memory freed
-			if deleteButton.Clicked() {
-				numfilesdeleted, sizeliberated = DeleteFiles(applogic.Selfiles)
+			// Show the quarantined files to restore them
+			if quarantineButton.Clicked() {
+				applogic.OpenQuarantine()
+				applogic.Appstate = guiutils.QuarantineS
+			}
+			if quarantineBackButton.Clicked() {
 				applogic.Appstate = guiutils.HomeS
 			}
 			// ACTIONS TO CHANGE THE STATE OF THE APPLICATION ***
@@ -278,20 +366,50 @@ func Run(win *app.Window) error {
 			switch applogic.Appstate {
 
 			case guiutils.HomeS:
-				applogic.HomePage(gtx, &scanButton, &initialPathInput, numfilesdeleted, sizeliberated)
+				applogic.HomePage(gtx, &scanButton, &loadSnapshotButton, &importNcduButton, &quarantineButton, &initialPathInput, &oneFileSystem, &symlinkPolicy)
 
 			case guiutils.LoadingFilesS:
-				applogic.ShowLoadingPage(gtx, totalFilesReadShow)
+				applogic.ShowLoadingPage(gtx, &cancelScanButton)
 
 			case guiutils.SelFilesS:
-				applogic.ShowFiles(gtx, &nextButton, &filelist)
+				applogic.ShowFiles(gtx, &nextButton, &newScanButton, &saveSnapshotButton, &exportNcduButton, &compareButton, &filelist, &showSkippedPaths, &skippedlist, &showDiskUsage)
 
 			case guiutils.DelFilesS:
-				applogic.ShowDeletingPage(gtx, &comeBackButton, &copy2clipboard, &deleteButton, &filedeletelist)
+				applogic.ShowDeletingPage(gtx, &comeBackButton, &copy2clipboard, &deleteButton, &deleteMode, &dryRun, &filedeletelist)
+
+			case guiutils.DiffS:
+				applogic.ShowDiff(gtx, &diffBackButton, &difflist)
+
+			case guiutils.DeletingS:
+				applogic.ShowDeletingProgress(gtx)
+
+			case guiutils.DelResultsS:
+				applogic.ShowDeletionResults(gtx, &resultsBackButton, &retryButton, &resultlist)
+
+			case guiutils.QuarantineS:
+				applogic.ShowQuarantine(gtx, &quarantineBackButton, &quarantinelist)
 
 			}
 			// STATES OF THE APPLICATION ***
 
+			// Scan again the folder whose Refresh button was clicked, showing the loading page like a new scan
+			if folder := applogic.FolderToRefresh; folder != nil {
+				applogic.FolderToRefresh = nil
+				applogic.Appstate = guiutils.LoadingFilesS
+
+				var ctx context.Context
+				ctx, cancelScan = context.WithCancel(context.Background())
+				refreshLoadingChann := make(chan files.Progress) // Used to transmit how far the scan has gone
+				applogic.ScanProgress = files.Progress{}
+				applogic.ScanStarted = time.Now()
+				applogic.ScanEstimate = nil
+				applogic.ScanFraction, applogic.ScanETA = 0, 0
+
+				go applogic.ReportProgress(win, refreshLoadingChann)
+				go applogic.RefreshFolder(ctx, folder, refreshLoadingChann)
+				win.Invalidate()
+			}
+
 			e.Frame(gtx.Ops)
 
 		}
@@ -299,6 +417,35 @@ func Run(win *app.Window) error {
 }
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gocleasy [FLAGS]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Opens the window of gocleasy, use gocleasy-cli to run it in a terminal.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
+		flag.PrintDefaults()
+	}
+	snapshotpath := flag.String("snapshot", "", "Open a scan saved with \"Save Snapshot\" instead of the home page")
+	ncdupath := flag.String("ncdu", "", "Open an ncdu JSON export to plan what to delete")
+	retention := flag.Duration("retention", quarantine.DefaultRetention, "How long quarantined files are kept before purging them")
+	flag.Parse()
+	// Purging with no retention would empty the quarantine at once
+	if *retention <= 0 {
+		fmt.Fprintln(flag.CommandLine.Output(), "The retention must be positive, like 168h")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Free the space of the quarantined files kept for too long
+	go func() {
+		dir, err := quarantine.DefaultDir()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if _, err := quarantine.PurgeExpired(dir, *retention, time.Now()); err != nil {
+			log.Println(err)
+		}
+	}()
 
 	go func() {
 
@@ -309,7 +456,7 @@ func main() {
 		)
 
 		// Run main loop
-		if err := Run(w); err != nil {
+		if err := Run(w, *snapshotpath, *ncdupath, *retention); err != nil {
 			log.Fatal(err)
 		}
 		os.Exit(0)
@@ -0,0 +1,94 @@
+package ncdu
+
+import (
+	"bufio"
+	"encoding/json"
+	"gocleasy/files"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Write writes the tree in the ncdu export format, with the time of the scan. Folders are written as they are
+// visited, so the output is never fully held in memory
+func Write(w io.Writer, root *files.File, scanned time.Time) error {
+	bw := bufio.NewWriter(w)
+	metadata, err := json.Marshal(Metadata{Progname: "gocleasy", Timestamp: scanned.Unix()})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("[1,2,")
+	bw.Write(metadata)
+	bw.WriteString(",\n")
+	if err := writeFile(bw, root, nil); err != nil {
+		return err
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// WriteFile writes the tree in the ncdu export format to the file at path
+func WriteFile(path string, root *files.File, scanned time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, root, scanned); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeFile writes the entry of a file, or the array of a folder with its content
+func writeFile(bw *bufio.Writer, file *files.File, parent *files.File) error {
+	info := entry{
+		Name:      file.Name,
+		Asize:     file.Size,
+		Dsize:     file.Usage,
+		Ino:       file.Inode,
+		ReadError: file.Unreadable,
+		Uid:       file.Uid,
+		Gid:       file.Gid,
+		Mode:      unixMode(file.Mode),
+	}
+	if parent == nil || file.Device != parent.Device {
+		info.Dev = file.Device
+	}
+	if file.IsHardLinked() {
+		info.Hlnkc, info.Nlink = true, file.NumLinks
+	}
+	if !file.ModTime.IsZero() {
+		info.Mtime = file.ModTime.Time().Unix()
+	}
+	if file.IsDir {
+		// ncdu calculates the sizes of folders itself, only their own blocks are stored
+		info.Asize, info.Dsize = 0, 0
+	} else if file.LinkType != files.NotLink || (file.Mode != 0 && !file.Mode.IsRegular()) {
+		info.Notreg = true
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if !file.IsDir {
+		_, err = bw.Write(data)
+		return err
+	}
+
+	bw.WriteByte('[')
+	bw.Write(data)
+	for _, child := range file.Files {
+		bw.WriteString(",\n")
+		if err := writeFile(bw, child, file); err != nil {
+			return err
+		}
+	}
+	_, err = bw.WriteString("]")
+	return err
+}
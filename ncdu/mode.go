@@ -0,0 +1,83 @@
+package ncdu
+
+import (
+	"os"
+)
+
+// Type bits of the st_mode field of stat, which is what ncdu stores
+const (
+	unixTypeMask    = 0o170000
+	unixDir         = 0o040000
+	unixSymlink     = 0o120000
+	unixNamedPipe   = 0o010000
+	unixSocket      = 0o140000
+	unixCharDevice  = 0o020000
+	unixBlockDevice = 0o060000
+	unixRegular     = 0o100000
+	unixSetuid      = 0o4000
+	unixSetgid      = 0o2000
+	unixSticky      = 0o1000
+)
+
+// fileMode converts a stat mode to os.FileMode
+func fileMode(mode uint32) os.FileMode {
+	fileMode := os.FileMode(mode & 0o777)
+	switch mode & unixTypeMask {
+	case unixDir:
+		fileMode |= os.ModeDir
+	case unixSymlink:
+		fileMode |= os.ModeSymlink
+	case unixNamedPipe:
+		fileMode |= os.ModeNamedPipe
+	case unixSocket:
+		fileMode |= os.ModeSocket
+	case unixCharDevice:
+		fileMode |= os.ModeDevice | os.ModeCharDevice
+	case unixBlockDevice:
+		fileMode |= os.ModeDevice
+	}
+	if mode&unixSetuid != 0 {
+		fileMode |= os.ModeSetuid
+	}
+	if mode&unixSetgid != 0 {
+		fileMode |= os.ModeSetgid
+	}
+	if mode&unixSticky != 0 {
+		fileMode |= os.ModeSticky
+	}
+	return fileMode
+}
+
+// unixMode converts os.FileMode to a stat mode, 0 if the mode is unknown
+func unixMode(fileMode os.FileMode) uint32 {
+	if fileMode == 0 {
+		return 0
+	}
+	mode := uint32(fileMode.Perm())
+	switch {
+	case fileMode&os.ModeDir != 0:
+		mode |= unixDir
+	case fileMode&os.ModeSymlink != 0:
+		mode |= unixSymlink
+	case fileMode&os.ModeNamedPipe != 0:
+		mode |= unixNamedPipe
+	case fileMode&os.ModeSocket != 0:
+		mode |= unixSocket
+	case fileMode&os.ModeCharDevice != 0:
+		mode |= unixCharDevice
+	case fileMode&os.ModeDevice != 0:
+		mode |= unixBlockDevice
+	default:
+		mode |= unixRegular
+	}
+	if fileMode&os.ModeSetuid != 0 {
+		mode |= unixSetuid
+	}
+	if fileMode&os.ModeSetgid != 0 {
+		mode |= unixSetgid
+	}
+	if fileMode&os.ModeSticky != 0 {
+		mode |= unixSticky
+	}
+	return mode
+}
@@ -0,0 +1,242 @@
+// Package ncdu reads and writes the JSON export format of ncdu, so scans made with
+// ncdu on a server can be browsed in gocleasy and scans of gocleasy opened with ncdu.
+// The format is described in https://dev.yorhel.nl/ncdu/jsonfmt
+package ncdu
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gocleasy/files"
+	"io"
+	"os"
+	"time"
+)
+
+// MajorVersion and MinorVersion of the format written by Export
+const (
+	MajorVersion = 1
+	MinorVersion = 2
+)
+
+// ErrFormat is returned when reading something that is not an ncdu export
+var ErrFormat = errors.New("not an ncdu export")
+
+// Metadata is the information about the program that made the export
+type Metadata struct {
+	Progname  string `json:"progname"`
+	Progver   string `json:"progver,omitempty"`
+	Timestamp int64  `json:"timestamp"` // When the scan was made, in seconds since the epoch
+}
+
+// Export is a tree read from an ncdu export
+type Export struct {
+	Root     *files.File // Tree of files, the name of the root is the scanned path
+	Metadata Metadata
+}
+
+// Time returns when the scan was made
+func (e *Export) Time() time.Time {
+	return time.Unix(e.Metadata.Timestamp, 0)
+}
+
+// entry is the information ncdu stores about every file and folder
+type entry struct {
+	Name      string `json:"name"`
+	Asize     int64  `json:"asize,omitempty"`
+	Dsize     int64  `json:"dsize,omitempty"`
+	Dev       uint64 `json:"dev,omitempty"`
+	Ino       uint64 `json:"ino,omitempty"`
+	Hlnkc     bool   `json:"hlnkc,omitempty"`
+	Nlink     uint64 `json:"nlink,omitempty"`
+	ReadError bool   `json:"read_error,omitempty"`
+	Excluded  string `json:"excluded,omitempty"`
+	Notreg    bool   `json:"notreg,omitempty"`
+	Uid       uint32 `json:"uid,omitempty"`
+	Gid       uint32 `json:"gid,omitempty"`
+	Mode      uint32 `json:"mode,omitempty"`
+	Mtime     int64  `json:"mtime,omitempty"`
+}
+
+// Import reads an ncdu export. The tree is read as it is decoded, the export is
+// never fully held in memory
+func Import(r io.Reader) (*Export, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, ErrFormat
+	}
+	var major, minor int
+	if err := dec.Decode(&major); err != nil || major != MajorVersion {
+		return nil, ErrFormat
+	}
+	if err := dec.Decode(&minor); err != nil {
+		return nil, ErrFormat
+	}
+
+	export := &Export{}
+	if err := dec.Decode(&export.Metadata); err != nil {
+		return nil, fmt.Errorf("reading ncdu metadata: %w", err)
+	}
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, fmt.Errorf("reading ncdu root: %w", err)
+	}
+	root, err := readFolder(dec, nil, -1)
+	if err != nil {
+		return nil, fmt.Errorf("reading ncdu export: %w", err)
+	}
+	root.UpdateSize(-1)
+	export.Root = root
+	return export, nil
+}
+
+// ImportFile reads the ncdu export saved at path
+func ImportFile(path string) (*Export, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Import(file)
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, found %v", delim, token)
+	}
+	return nil
+}
+
+// readFolder reads a folder once its opening bracket has been read: its own entry
+// followed by the entries of files and the arrays of subfolders
+func readFolder(dec *json.Decoder, parent *files.File, level int) (*files.File, error) {
+	var info entry
+	if err := dec.Decode(&info); err != nil {
+		return nil, err
+	}
+	folder := newFile(info, parent, level)
+	folder.IsDir = true
+	folder.Files = []*files.File{}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case json.Delim('['):
+			subFolder, err := readFolder(dec, folder, level+1)
+			if err != nil {
+				return nil, err
+			}
+			folder.Files = append(folder.Files, subFolder)
+		case json.Delim('{'):
+			// Token consumed the brace, so the fields are decoded one by one
+			var info entry
+			if err := decodeFields(dec, &info); err != nil {
+				return nil, err
+			}
+			folder.Files = append(folder.Files, newFile(info, folder, level+1))
+		default:
+			return nil, fmt.Errorf("unexpected %v in %s", token, folder.Name)
+		}
+	}
+	return folder, expectDelim(dec, ']')
+}
+
+// decodeFields reads the fields of an object whose opening brace has been read
+func decodeFields(dec *json.Decoder, info *entry) error {
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v as field name", token)
+		}
+		if err := dec.Decode(info.field(key)); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// field returns where to decode the field called key
+func (e *entry) field(key string) interface{} {
+	switch key {
+	case "name":
+		return &e.Name
+	case "asize":
+		return &e.Asize
+	case "dsize":
+		return &e.Dsize
+	case "dev":
+		return &e.Dev
+	case "ino":
+		return &e.Ino
+	case "hlnkc":
+		return &e.Hlnkc
+	case "nlink":
+		return &e.Nlink
+	case "read_error":
+		return &e.ReadError
+	case "excluded":
+		return &e.Excluded
+	case "notreg":
+		return &e.Notreg
+	case "uid":
+		return &e.Uid
+	case "gid":
+		return &e.Gid
+	case "mode":
+		return &e.Mode
+	case "mtime":
+		return &e.Mtime
+	default:
+		// Fields of newer versions are ignored
+		return &json.RawMessage{}
+	}
+}
+
+// newFile creates the file described by the entry
+func newFile(info entry, parent *files.File, level int) *files.File {
+	file := &files.File{
+		Name:       info.Name,
+		Size:       info.Asize,
+		Usage:      info.Dsize,
+		Parent:     parent,
+		Level:      level,
+		Device:     info.Dev,
+		Inode:      info.Ino,
+		NumLinks:   info.Nlink,
+		Unreadable: info.ReadError,
+		Uid:        info.Uid,
+		Gid:        info.Gid,
+		Mode:       fileMode(info.Mode),
+	}
+	if file.Device == 0 && parent != nil {
+		// ncdu only writes the device when it changes
+		file.Device = parent.Device
+	}
+	if info.Hlnkc && file.NumLinks < 2 {
+		// Older versions only tell that there are more links
+		file.NumLinks = 2
+	}
+	if info.Mtime != 0 {
+		file.ModTime = files.NewUnixNano(time.Unix(info.Mtime, 0))
+	}
+	if file.Mode&os.ModeSymlink != 0 {
+		file.LinkType = files.Symlink
+	}
+	if file.Mode.IsDir() {
+		// Excluded folders are written like files
+		file.IsDir = true
+		file.Files = []*files.File{}
+	}
+	return file
+}
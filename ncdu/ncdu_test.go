@@ -0,0 +1,100 @@
+package ncdu
+
+import (
+	"bytes"
+	"gocleasy/files"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testExport = `[1, 2, {"progname": "ncdu", "progver": "1.18", "timestamp": 1700000000},
+[{"name": "/srv", "asize": 4096, "dsize": 4096, "dev": 2049, "ino": 2},
+  {"name": "big.log", "asize": 1000, "dsize": 4096, "ino": 10, "mtime": 1690000000, "uid": 1000, "gid": 100, "mode": 33188},
+  [{"name": "data", "asize": 4096, "dsize": 4096, "ino": 3, "newfield": [1, {"x": 2}]},
+    {"name": "a", "asize": 300, "dsize": 512, "ino": 11, "hlnkc": true, "nlink": 2},
+    {"name": "b", "asize": 300, "dsize": 512, "ino": 11, "hlnkc": true, "nlink": 2},
+    [{"name": "locked", "read_error": true}]
+  ],
+  {"name": "proc", "excluded": "kernfs", "mode": 16877},
+  {"name": "other", "dev": 2050, "excluded": "otherfs"}
+]]`
+
+func TestImport(t *testing.T) {
+	export, err := Import(strings.NewReader(testExport))
+	assert.NoError(t, err)
+	assert.Equal(t, "ncdu", export.Metadata.Progname)
+	assert.Equal(t, time.Unix(1700000000, 0), export.Time())
+
+	root := export.Root
+	assert.Equal(t, "/srv", root.Name)
+	assert.Equal(t, int64(1300), root.Size, "hard links should be counted once")
+	assert.Equal(t, int64(4608), root.Usage)
+	assert.Equal(t, int64(4), root.NumChildren)
+
+	log := files.FindTestFile(root, "big.log")
+	assert.Same(t, root, log.Parent)
+	assert.Equal(t, time.Unix(1690000000, 0), log.ModTime.Time())
+	assert.Equal(t, uint32(1000), log.Uid)
+	assert.Equal(t, os.FileMode(0o644), log.Mode)
+
+	data := files.FindTestFile(root, "data")
+	assert.True(t, data.IsDir)
+	assert.Equal(t, uint64(2049), data.Device, "the device should be inherited")
+	assert.True(t, files.FindTestFile(root, "a").IsHardLinked())
+	assert.True(t, files.FindTestFile(root, "locked").Unreadable)
+	assert.True(t, files.FindTestFile(root, "proc").IsDir)
+	assert.Equal(t, "/srv/data/a", files.FindTestFile(root, "a").Path())
+}
+
+func TestImportNotNcdu(t *testing.T) {
+	_, err := Import(strings.NewReader(`{"name": "x"}`))
+	assert.ErrorIs(t, err, ErrFormat)
+	_, err = Import(strings.NewReader(`[2, 0, {}]`))
+	assert.ErrorIs(t, err, ErrFormat)
+	_, err = Import(strings.NewReader(testExport[:200]))
+	assert.Error(t, err)
+}
+
+func TestWriteImport(t *testing.T) {
+	root := files.NewTestFolder("/srv",
+		files.NewTestFile("c", 100),
+		files.NewTestFolder("d",
+			files.NewTestFile("e", 50),
+			files.NewTestFile("f", 30),
+		),
+	)
+	files.FindTestFile(root, "c").Mode = 0o600
+	files.FindTestFile(root, "e").ModTime = files.NewUnixNano(time.Unix(1690000000, 0))
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, root, time.Unix(1700000000, 0)))
+	export, err := Import(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "gocleasy", export.Metadata.Progname)
+	assert.Equal(t, int64(1700000000), export.Metadata.Timestamp)
+	assert.Equal(t, root.Size, export.Root.Size)
+	assert.Equal(t, root.NumChildren, export.Root.NumChildren)
+	assert.Equal(t, os.FileMode(0o600), files.FindTestFile(export.Root, "c").Mode)
+	assert.Equal(t, time.Unix(1690000000, 0), files.FindTestFile(export.Root, "e").ModTime.Time())
+	assert.Equal(t, "/srv/d/f", files.FindTestFile(export.Root, "f").Path())
+}
+
+func TestModeConversion(t *testing.T) {
+	for _, mode := range []os.FileMode{
+		0o644,
+		os.ModeDir | 0o755,
+		os.ModeSymlink | 0o777,
+		os.ModeSetuid | 0o755,
+		os.ModeDevice | os.ModeCharDevice | 0o620,
+		os.ModeDevice | 0o660,
+	} {
+		assert.Equal(t, mode, fileMode(unixMode(mode)), mode.String())
+	}
+	assert.Equal(t, uint32(33188), unixMode(0o644))
+	assert.Equal(t, uint32(0), unixMode(0), "unknown modes should not be written")
+}
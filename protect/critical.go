@@ -0,0 +1,56 @@
+package protect
+
+import (
+	"gocleasy/files"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Folders of Unix systems that cannot be deleted with their content
+var unixSystemGlobs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/proc", "/sbin", "/sys",
+	"/usr/bin", "/usr/lib", "/usr/lib32", "/usr/lib64", "/usr/libexec", "/usr/sbin",
+}
+
+// Folders of Unix systems that cannot be deleted, although their content can
+var unixSystemPaths = []string{
+	"/", "/home", "/media", "/mnt", "/opt", "/root", "/run", "/srv", "/tmp", "/usr",
+	"/usr/local", "/usr/share", "/var", "/var/cache", "/var/lib", "/var/log", "/var/tmp",
+}
+
+// criticalPaths returns the folders of the system that cannot be deleted, although
+// their content can, and the ones that cannot be deleted with their content. They
+// are found from the root scanned to analyze the whole disk
+func criticalPaths() ([]string, []string) {
+	root := files.RootPath()
+	switch runtime.GOOS {
+	case "windows":
+		systemroot := os.Getenv("SystemRoot")
+		if systemroot == "" {
+			systemroot = filepath.Join(root, "Windows")
+		}
+		return []string{
+			root,
+			filepath.Join(root, "Program Files"),
+			filepath.Join(root, "Program Files (x86)"),
+			filepath.Join(root, "ProgramData"),
+			filepath.Join(root, "Users"),
+		}, []string{
+			systemroot,
+			filepath.Join(root, "Boot"),
+			filepath.Join(root, "Recovery"),
+			filepath.Join(root, "System Volume Information"),
+		}
+	case "darwin":
+		// macOS
+		return append(unixSystemPaths, "/Applications", "/Library", "/Users", "/Volumes", "/private", "/private/var"),
+			append(unixSystemGlobs, "/System", "/private/etc", "/private/var/db")
+	case "ios":
+		// iOS apps are sandboxed, only their own files can be deleted
+		return []string{root}, []string{}
+	default:
+		// Linux, Android and other Unix systems like the BSDs have the same folders
+		return unixSystemPaths, unixSystemGlobs
+	}
+}
@@ -0,0 +1,209 @@
+package protect
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gocleasy/files"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrProtected is the error wrapped by ProtectedError, to check it with errors.Is
+var ErrProtected = errors.New("protected path")
+
+// ProtectedError reports that deleting Path would delete the protected path Protected
+type ProtectedError struct {
+	Path      string
+	Protected string
+}
+
+func (e *ProtectedError) Error() string {
+	switch {
+	case samePath(e.Path, e.Protected):
+		return fmt.Sprintf("%s is protected", e.Path)
+	case isInside(e.Path, e.Protected):
+		return fmt.Sprintf("%s is inside the protected %s", e.Path, e.Protected)
+	}
+	return fmt.Sprintf("%s contains the protected %s", e.Path, e.Protected)
+}
+
+func (e *ProtectedError) Unwrap() error {
+	return ErrProtected
+}
+
+// Guard says which files cannot be deleted
+type Guard struct {
+	Paths []string // Folders that cannot be deleted, nor the folders containing them, although their content can
+	Globs []string // Patterns of paths that cannot be deleted with their content, matched with the name if they have no separator
+}
+
+// Default protects the critical paths of the system, the home folder, the folder
+// scanned and the patterns listed in ~/.gocleasy/protected. The scan root is not
+// protected if it is empty
+func Default(scanroot string) *Guard {
+	paths, globs := criticalPaths()
+	guard := &Guard{Paths: paths, Globs: globs}
+	if home, err := os.UserHomeDir(); err == nil {
+		guard.Paths = append(guard.Paths, home)
+	}
+	if scanroot != "" {
+		// Files are checked with their absolute path, a relative root would never match
+		if abs, err := filepath.Abs(scanroot); err == nil {
+			scanroot = abs
+		}
+		guard.Paths = append(guard.Paths, scanroot)
+	}
+	guard.Globs = append(guard.Globs, ReadProtectedFile()...)
+	return guard
+}
+
+// ReadProtectedFile returns the patterns in ~/.gocleasy/protected, one per line.
+// Empty lines and lines starting with # are skipped and ~/ is the home folder
+func ReadProtectedFile() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Println("Wasn't able to retrieve the home folder of the user")
+		return []string{}
+	}
+	protectedFile, err := os.Open(filepath.Join(home, ".gocleasy", "protected"))
+	if os.IsNotExist(err) {
+		return []string{}
+	}
+	if err != nil {
+		log.Printf("Failed to read the protected paths because %s\n", err.Error())
+		return []string{}
+	}
+	defer protectedFile.Close()
+	scanner := bufio.NewScanner(protectedFile)
+	globs := []string{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "~/") {
+			line = filepath.Join(home, line[2:])
+		}
+		globs = append(globs, filepath.FromSlash(line))
+	}
+	return globs
+}
+
+// Check returns a ProtectedError if deleting the file would delete a protected path:
+// the file itself, a folder it is inside or a file it contains. Paths followed
+// through symbolic links are checked where the links point too
+func (g *Guard) Check(file *files.File) error {
+	path, err := filepath.Abs(file.Path())
+	if err != nil {
+		return err
+	}
+	if err := g.check(file, path); err != nil {
+		return err
+	}
+	// Deleting removes the file itself, not where it points if it is a link
+	if resolved := resolveParent(path); resolved != path {
+		return g.check(file, resolved)
+	}
+	return nil
+}
+
+func (g *Guard) check(file *files.File, path string) error {
+	for _, protected := range g.Paths {
+		for _, protected := range []string{filepath.Clean(protected), resolve(protected)} {
+			if samePath(path, protected) || isInside(protected, path) {
+				return &ProtectedError{Path: path, Protected: protected}
+			}
+		}
+	}
+	// The folders containing the file protect their content
+	for folder := path; ; folder = filepath.Dir(folder) {
+		if g.matches(folder) {
+			return &ProtectedError{Path: path, Protected: folder}
+		}
+		if filepath.Dir(folder) == folder {
+			break
+		}
+	}
+	if protected := g.protectedInside(file, path); protected != "" {
+		return &ProtectedError{Path: path, Protected: protected}
+	}
+	return nil
+}
+
+// protectedInside returns the first protected path among the files scanned inside
+// the folder at path, or an empty string if there is none. The content of folders
+// followed through symbolic links is matched where the links point
+func (g *Guard) protectedInside(folder *files.File, path string) string {
+	for _, file := range folder.Files {
+		child := filepath.Join(path, file.Name)
+		if g.matches(child) {
+			return child
+		}
+		if file.IsDir && file.LinkType == files.Symlink {
+			child = resolve(child)
+		}
+		if protected := g.protectedInside(file, child); protected != "" {
+			return protected
+		}
+	}
+	return ""
+}
+
+// resolveParent returns path with the symbolic links of the folders containing it
+// resolved, the file itself is kept as it is
+func resolveParent(path string) string {
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil || filepath.Dir(path) == path {
+		return path
+	}
+	return filepath.Join(parent, filepath.Base(path))
+}
+
+// resolve returns path with its symbolic links resolved, or cleaned if it does not exist
+func resolve(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return resolved
+}
+
+// matches checks if any of the patterns matches path, or its name for patterns without separator
+func (g *Guard) matches(path string) bool {
+	for _, glob := range g.Globs {
+		name := path
+		if !strings.ContainsRune(glob, filepath.Separator) {
+			name = filepath.Base(path)
+		}
+		if matched, _ := filepath.Match(foldCase(filepath.Clean(glob)), foldCase(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isInside checks if path is inside folder
+func isInside(path string, folder string) bool {
+	return strings.HasPrefix(foldCase(path), foldCase(strings.TrimSuffix(folder, string(filepath.Separator))+string(filepath.Separator)))
+}
+
+// samePath checks if both paths are the same file
+func samePath(path string, other string) bool {
+	return foldCase(path) == foldCase(other)
+}
+
+// caseInsensitive tells if paths with different case are the same file, as in
+// the usual filesystems of Windows and macOS, where %SystemRoot% is often C:\WINDOWS
+var caseInsensitive = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// foldCase returns the path in lower case if the case of paths does not matter
+func foldCase(path string) string {
+	if caseInsensitive {
+		return strings.ToLower(path)
+	}
+	return path
+}
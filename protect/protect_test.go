@@ -0,0 +1,121 @@
+package protect
+
+import (
+	"errors"
+	"gocleasy/files"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	root := files.NewTestFolder("/data",
+		files.NewTestFolder("photos",
+			files.NewTestFile("a.jpg", 10),
+		),
+		files.NewTestFolder("work",
+			files.NewTestFolder("keys",
+				files.NewTestFile("passwords.kdbx", 5),
+			),
+			files.NewTestFile("notes.txt", 5),
+		),
+		files.NewTestFile("big.iso", 100),
+	)
+	guard := &Guard{Paths: []string{"/data/photos"}, Globs: []string{"*.kdbx", "/data/archive"}}
+
+	assert.NoError(t, guard.Check(files.FindTestFile(root, "a.jpg")), "the content of protected paths can be deleted")
+	assert.NoError(t, guard.Check(files.FindTestFile(root, "notes.txt")))
+	assert.NoError(t, guard.Check(files.FindTestFile(root, "big.iso")))
+
+	err := guard.Check(files.FindTestFile(root, "photos"))
+	assert.ErrorIs(t, err, ErrProtected)
+	assert.Equal(t, "/data/photos is protected", err.Error())
+	assert.Equal(t, "/data contains the protected /data/photos", guard.Check(root).Error())
+
+	err = guard.Check(files.FindTestFile(root, "work"))
+	assert.ErrorIs(t, err, ErrProtected)
+	assert.Equal(t, "/data/work contains the protected /data/work/keys/passwords.kdbx", err.Error())
+	assert.Error(t, guard.Check(files.FindTestFile(root, "passwords.kdbx")))
+
+	archive := &files.File{Name: "/data/archive/2020", IsDir: true}
+	assert.Equal(t, "/data/archive/2020 is inside the protected /data/archive", guard.Check(archive).Error())
+	assert.NoError(t, guard.Check(&files.File{Name: "/data/archived"}))
+}
+
+func TestDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".gocleasy"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(home, ".gocleasy", "protected"), []byte("# Never delete\n\n~/photos\n*.kdbx\n"), 0644))
+	assert.Equal(t, []string{filepath.Join(home, "photos"), "*.kdbx"}, ReadProtectedFile())
+
+	guard := Default(filepath.Join(home, "scanned"))
+	var protectedError *ProtectedError
+	assert.True(t, errors.As(guard.Check(&files.File{Name: home, IsDir: true}), &protectedError), "the home folder should be protected")
+	assert.Error(t, guard.Check(&files.File{Name: filepath.Join(home, "scanned"), IsDir: true}), "the scan root should be protected")
+	assert.Error(t, guard.Check(&files.File{Name: filepath.Join(home, "photos", "a.jpg")}))
+	assert.NoError(t, guard.Check(&files.File{Name: filepath.Join(home, "scanned", "big.iso")}))
+	if filepath.Separator == '/' {
+		assert.Error(t, guard.Check(&files.File{Name: "/usr", IsDir: true}))
+		assert.Error(t, guard.Check(&files.File{Name: "/etc/hosts"}))
+	}
+}
+
+func TestCheckSymlinks(t *testing.T) {
+	// The temporary folder may be behind a link too, like in macOS
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "real", "keys"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "real", "keys", "id_rsa"), []byte("key"), 0o600))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "link")))
+	guard := &Guard{Paths: []string{filepath.Join(dir, "real", "keys")}, Globs: []string{filepath.Join(dir, "real", "keys", "*")}}
+
+	// Deleting through the link deletes the real files
+	err = guard.Check(&files.File{Name: filepath.Join(dir, "link", "keys"), IsDir: true})
+	assert.ErrorIs(t, err, ErrProtected)
+	assert.Error(t, guard.Check(&files.File{Name: filepath.Join(dir, "link", "keys", "id_rsa")}))
+	assert.NoError(t, guard.Check(&files.File{Name: filepath.Join(dir, "link")}), "deleting the link itself should not delete what it points to")
+
+	// The content of a followed link is matched where it points
+	scan := files.NewTestFolder(filepath.Join(dir, "scan"),
+		files.NewTestFolder("followed",
+			files.NewTestFolder("keys",
+				files.NewTestFile("id_rsa", 3),
+			),
+		),
+	)
+	files.FindTestFile(scan, "followed").LinkType = files.Symlink
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "scan"), 0o755))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "scan", "followed")))
+	guard = &Guard{Globs: []string{filepath.Join(dir, "real", "keys", "*")}}
+	assert.Equal(t, filepath.Join(dir, "real", "keys", "id_rsa"), guard.protectedInside(scan, filepath.Join(dir, "scan")))
+}
+
+func TestCheckCaseInsensitive(t *testing.T) {
+	defer func(saved bool) { caseInsensitive = saved }(caseInsensitive)
+	caseInsensitive = true
+	guard := &Guard{Paths: []string{"/Users"}, Globs: []string{"/System", "*.KDBX"}}
+	assert.Error(t, guard.Check(&files.File{Name: "/users", IsDir: true}))
+	assert.Error(t, guard.Check(&files.File{Name: "/system/library"}))
+	assert.Error(t, guard.Check(&files.File{Name: "/data/passwords.kdbx"}))
+	assert.NoError(t, guard.Check(&files.File{Name: "/users/me/big.iso"}))
+
+	caseInsensitive = false
+	assert.NoError(t, guard.Check(&files.File{Name: "/system/library"}))
+}
+
+func TestDefaultRelativeScanRoot(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "scanned"), 0o755))
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	guard := Default(filepath.Join(".", "scanned", "."))
+	root := files.NewTestFolder("scanned", files.NewTestFile("big.iso", 100))
+	assert.ErrorIs(t, guard.Check(root), ErrProtected, "a relative scan root should be protected")
+	assert.NoError(t, guard.Check(files.FindTestFile(root, "big.iso")))
+}
@@ -0,0 +1,330 @@
+// Package quarantine keeps deleted files for a while in a folder managed by gocleasy,
+// so a deletion can be undone until the files are purged
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gocleasy/files"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultRetention is how long quarantined files are kept when no other time is chosen
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Layout of the folder of a batch
+const (
+	manifestName = "manifest.json" // What the batch contains and where it was
+	filesDir     = "files"         // The files quarantined, named by their position in the manifest
+)
+
+// Format of the identifier of a batch, which is also the name of its folder
+const idFormat = "20060102-150405.000000000"
+
+var (
+	ErrExists          = errors.New("a file already exists in its original path")
+	ErrOtherFilesystem = errors.New("it is in another filesystem than the quarantine and cannot be moved without copying it")
+	ErrRetention       = errors.New("the retention of the quarantine must be positive")
+)
+
+// Item is a file or folder kept in quarantine with what is needed to put it back
+type Item struct {
+	Path     string      `json:"path"` // Where the file was
+	Name     string      `json:"name"` // Name of the file in the folder of the batch
+	IsDir    bool        `json:"is_dir"`
+	NumFiles int64       `json:"num_files"`
+	Size     int64       `json:"size"` // Space used in the disk
+	Mode     os.FileMode `json:"mode"`
+	ModTime  time.Time   `json:"mod_time"`
+	Uid      uint32      `json:"uid"`
+	Gid      uint32      `json:"gid"`
+}
+
+// Batch is the files quarantined in one deletion
+type Batch struct {
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	Items []*Item   `json:"items"`
+
+	Dir string `json:"-"` // Folder of the batch
+}
+
+// DefaultDir returns the folder where gocleasy keeps the quarantined files
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gocleasy", "quarantine"), nil
+}
+
+// NewBatch creates in dir an empty batch for the files deleted at t
+func NewBatch(dir string, t time.Time) (*Batch, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	id := t.Format(idFormat)
+	for i := 2; ; i++ {
+		batch := &Batch{ID: id, Time: t, Dir: filepath.Join(dir, id)}
+		err := os.Mkdir(batch.Dir, 0o700)
+		if os.IsExist(err) {
+			id = fmt.Sprintf("%s-%d", t.Format(idFormat), i)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Mkdir(filepath.Join(batch.Dir, filesDir), 0o700); err != nil {
+			return nil, err
+		}
+		return batch, batch.save()
+	}
+}
+
+// Expires returns when the batch is purged if it is kept for retention
+func (b *Batch) Expires(retention time.Duration) time.Time {
+	return b.Time.Add(retention)
+}
+
+// Size returns the space the batch uses in the disk
+func (b *Batch) Size() int64 {
+	var size int64
+	for _, item := range b.Items {
+		size += item.Size
+	}
+	return size
+}
+
+// NumFiles returns how many files there are in the batch
+func (b *Batch) NumFiles() int64 {
+	var numfiles int64
+	for _, item := range b.Items {
+		numfiles += item.NumFiles
+	}
+	return numfiles
+}
+
+// Add moves the file at path into the batch, saving where it was and its metadata
+// in the manifest. numfiles and size describe what the file contains
+func (b *Batch) Add(path string, numfiles int64, size int64) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	file, err := files.Stat(path)
+	if err != nil {
+		return err
+	}
+	item := &Item{
+		Path:     path,
+		Name:     strconv.Itoa(len(b.Items)),
+		IsDir:    file.IsDir,
+		NumFiles: numfiles,
+		Size:     size,
+		Mode:     file.Mode,
+		ModTime:  file.ModTime.Time(),
+		Uid:      file.Uid,
+		Gid:      file.Gid,
+	}
+	// The manifest is saved first, a file moved without it could not be found to restore it
+	b.Items = append(b.Items, item)
+	if err := b.save(); err != nil {
+		b.Items = b.Items[:len(b.Items)-1]
+		return err
+	}
+	if err := os.Rename(path, b.itemPath(item)); err != nil {
+		b.Items = b.Items[:len(b.Items)-1]
+		if saveErr := b.save(); saveErr != nil {
+			log.Println(saveErr)
+		}
+		if errors.Is(err, syscall.EXDEV) {
+			return &os.PathError{Op: "quarantine", Path: path, Err: ErrOtherFilesystem}
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckFilesystem returns ErrOtherFilesystem if the file at path is in another
+// filesystem than the quarantine in dir, where it cannot be moved. The quarantine
+// may not exist yet, then the folder that would contain it is checked
+func CheckFilesystem(dir string, path string) error {
+	file, err := files.Stat(path)
+	if err != nil {
+		return err
+	}
+	for {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			quarantine, err := files.Stat(resolved)
+			if err != nil {
+				return err
+			}
+			// Devices are unknown on some systems
+			if file.Device != 0 && quarantine.Device != 0 && file.Device != quarantine.Device {
+				return &os.PathError{Op: "quarantine", Path: path, Err: ErrOtherFilesystem}
+			}
+			return nil
+		}
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// Close removes the batch if nothing was added to it
+func (b *Batch) Close() error {
+	if len(b.Items) > 0 {
+		return nil
+	}
+	return os.RemoveAll(b.Dir)
+}
+
+// Restore puts every file of the batch back where it was, with its permissions and
+// modification time. Files are not restored over others created since then, they
+// stay in the batch. The batch is removed when all its files are restored
+func (b *Batch) Restore() error {
+	var kept []*Item
+	var firstErr error
+	for _, item := range b.Items {
+		if err := b.restore(item); err != nil {
+			kept = append(kept, item)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	b.Items = kept
+	if len(kept) == 0 {
+		return os.RemoveAll(b.Dir)
+	}
+	if err := b.save(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d files could not be restored: %w", len(kept), firstErr)
+}
+
+func (b *Batch) restore(item *Item) error {
+	if _, err := os.Lstat(item.Path); err == nil {
+		return &os.PathError{Op: "restore", Path: item.Path, Err: ErrExists}
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(b.itemPath(item), item.Path); err != nil {
+		return err
+	}
+	// Renaming keeps the metadata, this only fixes what was changed in quarantine
+	if item.Mode&os.ModeSymlink == 0 {
+		os.Chmod(item.Path, item.Mode.Perm())
+		os.Chtimes(item.Path, item.ModTime, item.ModTime)
+	}
+	if os.Getuid() == 0 {
+		os.Lchown(item.Path, int(item.Uid), int(item.Gid))
+	}
+	return nil
+}
+
+// Purge deletes the batch and its files for good
+func (b *Batch) Purge() error {
+	// Folders without write permission would stop their content from being removed
+	filepath.WalkDir(b.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			os.Chmod(path, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(b.Dir)
+}
+
+func (b *Batch) itemPath(item *Item) string {
+	return filepath.Join(b.Dir, filesDir, item.Name)
+}
+
+// save writes the manifest of the batch, replacing the previous one only when the
+// new one is complete
+func (b *Batch) save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifest := filepath.Join(b.Dir, manifestName)
+	tmp := manifest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, manifest)
+}
+
+// Load reads the batch in the folder dir
+func Load(dir string) (*Batch, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	batch := &Batch{}
+	if err := json.Unmarshal(data, batch); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	batch.Dir = dir
+	return batch, nil
+}
+
+// List returns the batches in the quarantine folder dir, the newest first. Folders
+// that are not batches are skipped
+func List(dir string) ([]*Batch, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var batches []*Batch
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		batch, err := Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		batches = append(batches, batch)
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].Time.After(batches[j].Time)
+	})
+	return batches, nil
+}
+
+// PurgeExpired deletes for good the batches in dir kept for longer than retention
+// and returns them. A retention that is not positive is refused, it would purge
+// every batch
+func PurgeExpired(dir string, retention time.Duration, now time.Time) ([]*Batch, error) {
+	if retention <= 0 {
+		return nil, ErrRetention
+	}
+	batches, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var purged []*Batch
+	for _, batch := range batches {
+		if batch.Expires(retention).After(now) {
+			continue
+		}
+		if err := batch.Purge(); err != nil {
+			return purged, err
+		}
+		purged = append(purged, batch)
+	}
+	return purged, nil
+}
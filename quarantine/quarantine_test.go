@@ -0,0 +1,130 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRestore(t *testing.T) {
+	dir, quarantined := t.TempDir(), t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0o640))
+	modtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "c"), modtime, modtime))
+
+	deleted := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	batch, err := NewBatch(quarantined, deleted)
+	assert.NoError(t, err)
+	assert.NoError(t, batch.Add(filepath.Join(dir, "d"), 1, 50))
+	assert.NoError(t, batch.Add(filepath.Join(dir, "c"), 1, 100))
+	assert.NoError(t, batch.Close())
+	assert.NoDirExists(t, filepath.Join(dir, "d"))
+	assert.NoFileExists(t, filepath.Join(dir, "c"))
+	assert.Error(t, batch.Add(filepath.Join(dir, "missing"), 1, 0))
+
+	batches, err := List(quarantined)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	loaded := batches[0]
+	assert.Equal(t, batch.ID, loaded.ID)
+	assert.True(t, deleted.Equal(loaded.Time))
+	assert.Equal(t, int64(150), loaded.Size())
+	assert.Equal(t, int64(2), loaded.NumFiles())
+	assert.Equal(t, filepath.Join(dir, "c"), loaded.Items[1].Path)
+	assert.Equal(t, os.FileMode(0o640), loaded.Items[1].Mode)
+	assert.True(t, modtime.Equal(loaded.Items[1].ModTime))
+
+	// A file created since then is not replaced
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), []byte("new"), 0o644))
+	assert.ErrorIs(t, loaded.Restore(), ErrExists)
+	assert.FileExists(t, filepath.Join(dir, "d", "e"))
+	assert.Len(t, loaded.Items, 1)
+	content, err := os.ReadFile(filepath.Join(dir, "c"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "c")))
+	assert.NoError(t, loaded.Restore())
+	info, err := os.Stat(filepath.Join(dir, "c"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), info.Size())
+	assert.True(t, modtime.Equal(info.ModTime()))
+	assert.NoDirExists(t, loaded.Dir, "a batch restored completely should be removed")
+}
+
+func TestAddSaveFailure(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0o644))
+	batch, err := NewBatch(t.TempDir(), time.Now())
+	assert.NoError(t, err)
+	// The manifest cannot be written over a folder
+	assert.NoError(t, os.Mkdir(filepath.Join(batch.Dir, manifestName+".tmp"), 0o700))
+
+	assert.Error(t, batch.Add(filepath.Join(dir, "c"), 1, 100))
+	assert.FileExists(t, filepath.Join(dir, "c"), "the file should not be moved if the manifest cannot be saved")
+	assert.Empty(t, batch.Items)
+}
+
+func TestCheckFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0o644))
+	assert.NoError(t, CheckFilesystem(filepath.Join(dir, "quarantine"), filepath.Join(dir, "c")), "a quarantine not created yet should be in the filesystem of its folder")
+	assert.Error(t, CheckFilesystem(filepath.Join(dir, "quarantine"), filepath.Join(dir, "missing")))
+	if runtime.GOOS == "linux" {
+		err := CheckFilesystem(filepath.Join(dir, "quarantine"), "/proc/self/status")
+		assert.ErrorIs(t, err, ErrOtherFilesystem)
+	}
+}
+
+func TestEmptyBatch(t *testing.T) {
+	quarantined := t.TempDir()
+	now := time.Now()
+	first, err := NewBatch(quarantined, now)
+	assert.NoError(t, err)
+	second, err := NewBatch(quarantined, now)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Dir, second.Dir, "batches made at the same time should not share a folder")
+	assert.NoError(t, first.Close())
+	assert.NoDirExists(t, first.Dir)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	dir, quarantined := t.TempDir(), t.TempDir()
+	now := time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC)
+	for i, age := range []time.Duration{40 * 24 * time.Hour, 10 * 24 * time.Hour} {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		assert.NoError(t, os.MkdirAll(filepath.Join(path, "locked"), 0o755))
+		assert.NoError(t, os.Chmod(filepath.Join(path, "locked"), 0o555))
+		batch, err := NewBatch(quarantined, now.Add(-age))
+		assert.NoError(t, err)
+		assert.NoError(t, batch.Add(path, 0, 0))
+	}
+
+	purged, err := PurgeExpired(quarantined, DefaultRetention, now)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.NoDirExists(t, purged[0].Dir)
+	batches, err := List(quarantined)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, filepath.Join(dir, "b"), batches[0].Items[0].Path)
+	for _, retention := range []time.Duration{0, -time.Hour} {
+		purged, err = PurgeExpired(quarantined, retention, now)
+		assert.ErrorIs(t, err, ErrRetention)
+		assert.Empty(t, purged)
+	}
+	assert.DirExists(t, batches[0].Dir, "a wrong retention should not purge anything")
+	assert.NoError(t, batches[0].Purge())
+}
+
+func TestListMissing(t *testing.T) {
+	batches, err := List(filepath.Join(t.TempDir(), "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, batches)
+}
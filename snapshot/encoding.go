@@ -0,0 +1,358 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"gocleasy/files"
+	"io"
+	"os"
+	"time"
+)
+
+// Flags of each file in the snapshot
+const (
+	flagDir = 1 << iota
+	flagIncomplete
+	flagUnreadable
+	flagModTime
+	flagAccessTime
+	flagSlashSeparated
+)
+
+// Flags of the walk options in the header
+const (
+	optionOneFileSystem = 1 << iota
+	optionArena
+)
+
+// maxStringLen protects from allocating huge strings when reading corrupted snapshots
+const maxStringLen = 1 << 20
+
+// maxDepth protects from exhausting the stack when reading corrupted snapshots,
+// paths of real filesystems are much shorter than so many folders
+const maxDepth = 4096
+
+var errCorrupted = errors.New("corrupted snapshot")
+
+// encoder writes numbers as varints. The first error is kept and returned by flush
+type encoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{w: bufio.NewWriter(w)}
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.write(e.buf[:binary.PutUvarint(e.buf[:], v)])
+}
+
+func (e *encoder) varint(v int64) {
+	e.write(e.buf[:binary.PutVarint(e.buf[:], v)])
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.write([]byte(s))
+}
+
+func (e *encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (e *encoder) header(s *Snapshot) {
+	e.write([]byte(magic))
+	e.uvarint(Version)
+	e.varint(s.Time.UnixNano())
+
+	var options uint64
+	if s.Options.OneFileSystem {
+		options |= optionOneFileSystem
+	}
+	if s.Options.Arena {
+		options |= optionArena
+	}
+	e.uvarint(options)
+	e.uvarint(uint64(s.Options.Symlinks))
+
+	e.uvarint(uint64(len(s.Errors)))
+	for _, scanError := range s.Errors {
+		e.string(scanError.Path)
+		e.uvarint(uint64(scanError.Kind))
+		message := ""
+		if scanError.Err != nil {
+			message = scanError.Err.Error()
+		}
+		e.string(message)
+	}
+}
+
+// file writes the file and, for folders, everything inside it
+func (e *encoder) file(f *files.File) {
+	var flags uint64
+	if f.IsDir {
+		flags |= flagDir
+	}
+	if f.Incomplete {
+		flags |= flagIncomplete
+	}
+	if f.Unreadable {
+		flags |= flagUnreadable
+	}
+	if f.SlashSeparated {
+		flags |= flagSlashSeparated
+	}
+	if !f.ModTime.IsZero() {
+		flags |= flagModTime
+	}
+	if !f.AccessTime.IsZero() {
+		flags |= flagAccessTime
+	}
+	e.string(f.Name)
+	e.uvarint(flags)
+	e.uvarint(uint64(f.LinkType))
+	if f.LinkType != files.NotLink {
+		e.string(f.LinkTarget)
+	}
+	if !f.ModTime.IsZero() {
+		e.varint(int64(f.ModTime))
+	}
+	if !f.AccessTime.IsZero() {
+		e.varint(int64(f.AccessTime))
+	}
+	e.uvarint(uint64(f.Uid))
+	e.uvarint(uint64(f.Gid))
+	e.uvarint(uint64(f.Mode))
+	// Folders keep their inode to count only once the ones followed through several links
+	e.uvarint(f.Device)
+	e.uvarint(f.Inode)
+
+	if !f.IsDir {
+		// Sizes of folders are calculated again when reading
+		e.varint(f.Size)
+		e.varint(f.Usage)
+		e.uvarint(f.NumLinks)
+		return
+	}
+	e.uvarint(uint64(len(f.Files)))
+	for _, child := range f.Files {
+		e.file(child)
+	}
+}
+
+// decoder reads what encoder writes
+type decoder struct {
+	r     *bufio.Reader
+	names map[string]string // Shares the memory of repeated names
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReader(r), names: map[string]string{}}
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (d *decoder) varint() (int64, error) {
+	v, err := binary.ReadVarint(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if n > maxStringLen {
+		return "", errCorrupted
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) name() (string, error) {
+	name, err := d.string()
+	if err != nil {
+		return "", err
+	}
+	if interned, ok := d.names[name]; ok {
+		return interned, nil
+	}
+	d.names[name] = name
+	return name, nil
+}
+
+func (d *decoder) time() (time.Time, error) {
+	nanos, err := d.varint()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nanos), nil
+}
+
+func (d *decoder) unixNano() (files.UnixNano, error) {
+	nanos, err := d.varint()
+	return files.UnixNano(nanos), err
+}
+
+func (d *decoder) header() (*Snapshot, error) {
+	start := make([]byte, len(magic))
+	if _, err := io.ReadFull(d.r, start); err != nil || string(start) != magic {
+		return nil, ErrFormat
+	}
+	version, err := d.uvarint()
+	if err != nil {
+		return nil, ErrFormat
+	}
+	if version != Version {
+		return nil, ErrVersion
+	}
+
+	s := &Snapshot{}
+	if s.Time, err = d.time(); err != nil {
+		return nil, err
+	}
+	options, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	symlinks, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	s.Options = files.WalkOptions{
+		OneFileSystem: options&optionOneFileSystem != 0,
+		Arena:         options&optionArena != 0,
+		Symlinks:      files.SymlinkPolicy(symlinks),
+	}
+
+	numErrors, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < numErrors; i++ {
+		path, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		message, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		s.Errors = append(s.Errors, &files.ScanError{Path: path, Kind: files.ScanErrorKind(kind), Err: errors.New(message)})
+	}
+	return s, nil
+}
+
+// file reads a file and, for folders, everything inside it
+func (d *decoder) file(parent *files.File, level int) (*files.File, error) {
+	f := &files.File{Parent: parent, Level: level}
+	var err error
+	if f.Name, err = d.name(); err != nil {
+		return nil, err
+	}
+	flags, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	f.IsDir = flags&flagDir != 0
+	f.Incomplete = flags&flagIncomplete != 0
+	f.Unreadable = flags&flagUnreadable != 0
+	f.SlashSeparated = flags&flagSlashSeparated != 0
+
+	linkType, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	f.LinkType = files.LinkType(linkType)
+	if f.LinkType != files.NotLink {
+		if f.LinkTarget, err = d.string(); err != nil {
+			return nil, err
+		}
+	}
+	if flags&flagModTime != 0 {
+		if f.ModTime, err = d.unixNano(); err != nil {
+			return nil, err
+		}
+	}
+	if flags&flagAccessTime != 0 {
+		if f.AccessTime, err = d.unixNano(); err != nil {
+			return nil, err
+		}
+	}
+	var uid, gid, mode uint64
+	for _, v := range []*uint64{&uid, &gid, &mode} {
+		if *v, err = d.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+	f.Uid, f.Gid, f.Mode = uint32(uid), uint32(gid), os.FileMode(mode)
+	for _, v := range []*uint64{&f.Device, &f.Inode} {
+		if *v, err = d.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !f.IsDir {
+		if f.Size, err = d.varint(); err != nil {
+			return nil, err
+		}
+		if f.Usage, err = d.varint(); err != nil {
+			return nil, err
+		}
+		if f.NumLinks, err = d.uvarint(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	numFiles, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	// Do not trust the count to allocate, the snapshot may be corrupted
+	capacity := numFiles
+	if capacity > 1024 {
+		capacity = 1024
+	}
+	f.Files = make([]*files.File, 0, capacity)
+	if numFiles > 0 && level+1 >= maxDepth {
+		return nil, fmt.Errorf("%w: folders nested more than %d levels", ErrFormat, maxDepth)
+	}
+	for i := uint64(0); i < numFiles; i++ {
+		child, err := d.file(f, level+1)
+		if err != nil {
+			return nil, err
+		}
+		f.Files = append(f.Files, child)
+	}
+	return f, nil
+}
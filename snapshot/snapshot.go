@@ -0,0 +1,208 @@
+// Package snapshot saves scans to disk so they can be browsed later without scanning again
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"gocleasy/files"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version of the format written by Write. Read refuses snapshots of other versions
+const Version = 2
+
+// Extension of the snapshot files saved by gocleasy
+const Extension = ".gcsnap"
+
+// magic starts every snapshot, after decompressing it
+const magic = "GCSNAP"
+
+// ErrFormat is returned when reading something that is not a gocleasy snapshot
+var ErrFormat = errors.New("not a gocleasy snapshot")
+
+// ErrVersion is returned when reading a snapshot written with another version of the format
+var ErrVersion = errors.New("unsupported snapshot version")
+
+// Snapshot is a scan saved to disk
+type Snapshot struct {
+	Root    *files.File        // Tree of files, the name of the root is the scanned path
+	Errors  []*files.ScanError // Paths that could not be read during the scan
+	Time    time.Time          // When the scan was started
+	Options files.WalkOptions  // Options used for the scan
+}
+
+// Path returns the path that was scanned
+func (s *Snapshot) Path() string {
+	return s.Root.Name
+}
+
+// Write encodes the snapshot into w. The format is a gzip compressed stream
+// with a header followed by the tree in depth-first order
+func Write(w io.Writer, s *Snapshot) error {
+	zw := gzip.NewWriter(w)
+	enc := newEncoder(zw)
+	enc.header(s)
+	enc.file(s.Root)
+	if err := enc.flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read decodes a snapshot written by Write
+func Read(r io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, ErrFormat
+	}
+	defer zr.Close()
+
+	dec := newDecoder(zr)
+	s, err := dec.header()
+	if err != nil {
+		return nil, err
+	}
+	s.Root, err = dec.file(nil, -1)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	// Reading until the end verifies the gzip checksum
+	if extra, err := io.Copy(io.Discard, dec.r); err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	} else if extra > 0 {
+		return nil, fmt.Errorf("reading snapshot: %w", errCorrupted)
+	}
+	s.Root.UpdateSize(-1)
+	return s, nil
+}
+
+// Save writes the snapshot to the file at path, replacing it if it exists
+func Save(path string, s *Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file so an old snapshot is not lost if it fails
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, s); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the snapshot saved at path
+func Load(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// DefaultDir is where the GUI saves snapshots: ~/.gocleasy/snapshots
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gocleasy", "snapshots"), nil
+}
+
+// DefaultPath returns where to save in DefaultDir a scan started at t
+func DefaultPath(t time.Time) (string, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, t.Format("20060102-150405")+Extension), nil
+}
+
+// Latest returns the path of the most recent snapshot in dir
+func Latest(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), Extension) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no snapshots in %s: %w", dir, os.ErrNotExist)
+	}
+	// Names are the time of the scan so the last one is the newest
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// Previous returns the path of the newest snapshot in dir of the same scanned path
+// made before t, so the scan made at t can be compared with it
+func Previous(dir string, scanned string, before time.Time) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var previous string
+	var previousTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), Extension) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		scanTime, scanPath, err := readInfo(path)
+		if err != nil {
+			// Not every file in the folder has to be a valid snapshot
+			continue
+		}
+		if scanPath == scanned && scanTime.Before(before) && scanTime.After(previousTime) {
+			previous, previousTime = path, scanTime
+		}
+	}
+	if previous == "" {
+		return "", fmt.Errorf("no snapshots of %s before %s: %w", scanned, before.Format("2006-01-02 15:04"), os.ErrNotExist)
+	}
+	return previous, nil
+}
+
+// readInfo reads when the snapshot at path was made and the path that was scanned,
+// without reading the tree
+func readInfo(path string) (time.Time, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return time.Time{}, "", ErrFormat
+	}
+	defer zr.Close()
+
+	dec := newDecoder(zr)
+	s, err := dec.header()
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	// The tree starts with the name of the root
+	scanned, err := dec.string()
+	return s.Time, scanned, err
+}
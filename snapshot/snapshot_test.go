@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"gocleasy/files"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanTestDir(t *testing.T) *files.ScanResult {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d", "g"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0o600))
+	assert.NoError(t, os.Symlink("e", filepath.Join(dir, "d", "link")))
+	return files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{})
+}
+
+func TestWriteRead(t *testing.T) {
+	result := scanTestDir(t)
+	result.Errors = []*files.ScanError{{Path: "/x", Kind: files.PermissionDenied, Err: errors.New("denied")}}
+	s := &Snapshot{
+		Root:    result.Root,
+		Errors:  result.Errors,
+		Time:    time.Unix(1700000000, 5),
+		Options: files.WalkOptions{OneFileSystem: true, Symlinks: files.FollowSymlinks},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, s))
+	read, err := Read(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, s.Root, read.Root)
+	assert.Equal(t, s.Path(), read.Path())
+	assert.True(t, s.Time.Equal(read.Time))
+	assert.Equal(t, s.Options, read.Options)
+	assert.Len(t, read.Errors, 1)
+	assert.Equal(t, "/x", read.Errors[0].Path)
+	assert.Equal(t, files.PermissionDenied, read.Errors[0].Kind)
+	assert.EqualError(t, read.Errors[0].Err, "denied")
+}
+
+func TestSaveLoad(t *testing.T) {
+	result := scanTestDir(t)
+	path := filepath.Join(t.TempDir(), "snapshots", "a"+Extension)
+	assert.NoError(t, Save(path, &Snapshot{Root: result.Root, Time: time.Now()}))
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, result.Root.Size, loaded.Root.Size)
+	assert.Equal(t, result.Root.NumChildren, loaded.Root.NumChildren)
+	assert.Same(t, loaded.Root, loaded.Root.Lookup(filepath.Join(result.Root.Name, "d")).Parent)
+
+	latest, err := Latest(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Equal(t, path, latest)
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("not a snapshot")))
+	assert.ErrorIs(t, err, ErrFormat)
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, &Snapshot{Root: files.NewTestFolder("a", files.NewTestFile("b", 10))}))
+	data := buf.Bytes()
+	_, err = Read(bytes.NewReader(data[:len(data)-10]))
+	assert.Error(t, err, "truncated snapshots should not be read")
+}
+
+func TestReadTooDeep(t *testing.T) {
+	// Nesting that deep only happens in corrupted or crafted snapshots
+	root := files.NewTestFolder("a")
+	folder := root
+	for i := 0; i < maxDepth+10; i++ {
+		child := &files.File{Name: "d", IsDir: true, Parent: folder, Files: []*files.File{}}
+		folder.Files = append(folder.Files, child)
+		folder = child
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, &Snapshot{Root: root}))
+	_, err := Read(&buf)
+	assert.ErrorIs(t, err, ErrFormat)
+}
+
+func TestReadOtherVersion(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := newEncoder(zw)
+	enc.write([]byte(magic))
+	enc.uvarint(Version + 1)
+	assert.NoError(t, enc.flush())
+	assert.NoError(t, zw.Close())
+
+	_, err := Read(&buf)
+	assert.ErrorIs(t, err, ErrVersion)
+}
+
+func TestPrevious(t *testing.T) {
+	dir := t.TempDir()
+	save := func(name string, scanned string, at time.Time) string {
+		path := filepath.Join(dir, name+Extension)
+		assert.NoError(t, Save(path, &Snapshot{Root: files.NewTestFolder(scanned, files.NewTestFile("a", 10)), Time: at}))
+		return path
+	}
+	first := save("1", "/home", time.Unix(1000, 0))
+	second := save("2", "/home", time.Unix(2000, 0))
+	save("3", "/srv", time.Unix(2500, 0))
+	save("4", "/home", time.Unix(3000, 0))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+Extension), []byte("x"), 0o644))
+
+	previous, err := Previous(dir, "/home", time.Unix(3000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, second, previous)
+	previous, err = Previous(dir, "/home", time.Unix(2000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, first, previous)
+	_, err = Previous(dir, "/srv", time.Unix(2500, 0))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
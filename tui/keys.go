@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+// key is a key pressed, printable keys are their rune and the rest are negative
+type key rune
+
+// Keys sent as escape sequences
+const (
+	keyUp key = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+)
+
+// Keys sent as control characters
+const (
+	keyCtrlC     key = 0x03
+	keyTab       key = '\t'
+	keyEnter     key = '\r'
+	keyEsc       key = 0x1b
+	keySpace     key = ' '
+	keyBackspace key = 0x7f
+)
+
+// escapeSequences are the sequences sent by the terminal for the special keys,
+// after the escape character
+var escapeSequences = map[string]key{
+	"[A":  keyUp,
+	"OA":  keyUp,
+	"[B":  keyDown,
+	"OB":  keyDown,
+	"[C":  keyRight,
+	"OC":  keyRight,
+	"[D":  keyLeft,
+	"OD":  keyLeft,
+	"[5~": keyPageUp,
+	"[6~": keyPageDown,
+	"[H":  keyHome,
+	"OH":  keyHome,
+	"[1~": keyHome,
+	"[F":  keyEnd,
+	"OF":  keyEnd,
+	"[4~": keyEnd,
+}
+
+// parseKeys returns the keys read from the terminal in one read. An escape
+// sequence always arrives complete, so an escape at the end is the Esc key
+func parseKeys(input []byte) []key {
+	var keys []key
+	for len(input) > 0 {
+		if input[0] == byte(keyEsc) && len(input) > 1 {
+			k, size := parseEscape(input[1:])
+			if k != 0 {
+				keys = append(keys, k)
+			}
+			input = input[1+size:]
+			continue
+		}
+		r, size := utf8.DecodeRune(input)
+		input = input[size:]
+		if r == '\n' {
+			r = rune(keyEnter)
+		}
+		keys = append(keys, key(r))
+	}
+	return keys
+}
+
+// parseEscape parses the sequence after an escape character and returns the key
+// and the bytes used. Unknown sequences are skipped whole
+func parseEscape(input []byte) (key, int) {
+	if input[0] != '[' && input[0] != 'O' {
+		// Escape followed by another key
+		return keyEsc, 0
+	}
+	// The sequence ends with a letter or a tilde
+	for end := 1; end < len(input); end++ {
+		c := input[end]
+		if c == '~' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
+			k, ok := escapeSequences[string(input[:end+1])]
+			if !ok {
+				return 0, end + 1
+			}
+			return k, end + 1
+		}
+	}
+	return 0, len(input)
+}
@@ -0,0 +1,17 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []key{'j', keySpace, keyEnter, keyEnter}, parseKeys([]byte("j \r\n")))
+	assert.Equal(t, []key{keyUp, keyDown, keyRight, keyLeft}, parseKeys([]byte("\033[A\033[B\033OC\033[D")))
+	assert.Equal(t, []key{keyPageUp, keyPageDown, keyHome, keyEnd}, parseKeys([]byte("\033[5~\033[6~\033[H\033[4~")))
+	assert.Equal(t, []key{keyEsc}, parseKeys([]byte("\033")), "an escape alone is the Esc key")
+	assert.Equal(t, []key{keyEsc, 'q'}, parseKeys([]byte("\033q")))
+	assert.Equal(t, []key{'y'}, parseKeys([]byte("\033[1;5Ay")), "unknown sequences should be skipped")
+	assert.Equal(t, []key{'ñ', keyCtrlC}, parseKeys([]byte("ñ\x03")))
+}
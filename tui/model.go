@@ -0,0 +1,343 @@
+package tui
+
+import (
+	"fmt"
+	"gocleasy/cleanup"
+	"gocleasy/files"
+	"gocleasy/protect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+)
+
+type state int
+
+const (
+	selectState  state = iota // Show the files to be selected
+	confirmState              // Show the selected files to be deleted
+)
+
+// Lines of the screen that are not part of the list of files
+const (
+	headerLines = 2
+	footerLines = 2
+)
+
+// Escape sequences to highlight the row under the cursor
+const (
+	reverseVideo = "\033[7m"
+	resetVideo   = "\033[0m"
+)
+
+// model stores what the terminal shows and changes it with the keys pressed
+type model struct {
+	root      *files.File
+	selection cleanup.Selection
+	metric    files.SizeMetric
+	state     state
+
+	open    map[*files.File]bool // Folders showing their content
+	rows    []*files.File        // Files shown in the selection, in order
+	cursor  int                  // Row under the cursor
+	top     int                  // First row shown on the screen
+	height  int                  // Rows of the list in the last screen shown, used to page
+	message string               // Result of the last action
+
+	mode  cleanup.Mode   // How the selected files are deleted, changed in the confirmation
+	guard *protect.Guard // Paths that cannot be deleted
+}
+
+func newModel(root *files.File, metric files.SizeMetric, guard *protect.Guard) *model {
+	m := &model{
+		root:   root,
+		metric: metric,
+		guard:  guard,
+		mode:   cleanup.DefaultMode,
+		open:   map[*files.File]bool{},
+		height: 1,
+	}
+	m.refreshRows(nil)
+	return m
+}
+
+// refreshRows lists again the files shown, after folders are opened or closed or
+// files are deleted, and puts the cursor on the file when it is still shown
+func (m *model) refreshRows(cursor *files.File) {
+	m.rows = m.appendRows(m.rows[:0], m.root.Files)
+	for index, file := range m.rows {
+		if file == cursor {
+			m.cursor = index
+			return
+		}
+	}
+	m.moveCursor(0)
+}
+
+func (m *model) appendRows(rows []*files.File, children []*files.File) []*files.File {
+	for _, file := range children {
+		rows = append(rows, file)
+		if file.IsDir && m.open[file] {
+			rows = m.appendRows(rows, file.Files)
+		}
+	}
+	return rows
+}
+
+// moveCursor moves the cursor by delta rows without leaving the list
+func (m *model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// current returns the file under the cursor, nil when there are no files
+func (m *model) current() *files.File {
+	if m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor]
+}
+
+// update applies the key pressed and reports whether the program has to finish
+func (m *model) update(k key) bool {
+	if k == keyCtrlC || k == 'q' {
+		return true
+	}
+	switch m.state {
+	case selectState:
+		m.updateSelect(k)
+	case confirmState:
+		m.updateConfirm(k)
+	}
+	return false
+}
+
+func (m *model) updateSelect(k key) {
+	file := m.current()
+	switch k {
+	case keyUp, 'k':
+		m.moveCursor(-1)
+	case keyDown, 'j':
+		m.moveCursor(1)
+	case keyPageUp:
+		m.moveCursor(-m.height)
+	case keyPageDown:
+		m.moveCursor(m.height)
+	case keyHome, 'g':
+		m.moveCursor(-len(m.rows))
+	case keyEnd, 'G':
+		m.moveCursor(len(m.rows))
+	case keyRight, 'l':
+		if file != nil && file.IsDir {
+			m.open[file] = true
+			m.refreshRows(file)
+		}
+	case keyEnter:
+		if file != nil && file.IsDir {
+			m.open[file] = !m.open[file]
+			m.refreshRows(file)
+		}
+	case keyLeft, 'h':
+		if file == nil {
+			break
+		}
+		if file.IsDir && m.open[file] {
+			delete(m.open, file)
+		} else if file.Parent != m.root && file.Parent != nil {
+			// Go to the folder containing the file and close it
+			file = file.Parent
+			delete(m.open, file)
+		}
+		m.refreshRows(file)
+	case keySpace:
+		if file != nil {
+			m.selection.Toggle(file)
+			m.moveCursor(1)
+		}
+	case 'd', keyTab:
+		if m.selection.Len() == 0 {
+			m.message = "Select files with space before deleting them"
+			break
+		}
+		// Protected files are refused before asking for confirmation
+		for _, selected := range m.selection.Files {
+			if err := m.guard.Check(selected); err != nil {
+				m.message = fmt.Sprintf("Cannot delete the selection: %s, unselect it first", err)
+				return
+			}
+		}
+		m.message = ""
+		m.state = confirmState
+	}
+}
+
+func (m *model) updateConfirm(k key) {
+	switch k {
+	case 'y':
+		report := cleanup.Delete(m.root, &m.selection, m.mode, m.guard)
+		switch report.Mode {
+		case cleanup.MoveToTrashMode:
+			m.message = fmt.Sprintf("%s files moved to the trash, %s", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)))
+		case cleanup.QuarantineMode:
+			m.message = fmt.Sprintf("%s files quarantined, %s", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Bytes)))
+		default:
+			m.message = fmt.Sprintf("%s files deleted, %s freed", humanize.Comma(report.NumFiles), humanize.Bytes(uint64(report.Freed())))
+		}
+		if report.Gone > 0 {
+			m.message += fmt.Sprintf(", %d were already gone", report.Gone)
+		}
+		// What failed stays selected to try again
+		if failures := report.Failures(); len(failures) > 0 {
+			m.message += fmt.Sprintf(", %d could not be deleted and are still selected: %s", len(failures), failures[0].Err)
+		}
+		m.refreshRows(m.current())
+		m.state = selectState
+		// Deleting for good has to be chosen again every time
+		if m.mode == cleanup.PermanentMode {
+			m.mode = cleanup.DefaultMode
+		}
+	case 'p':
+		m.mode = nextMode(m.mode)
+	case 'n', 'b', keyEsc, keyBackspace:
+		m.state = selectState
+	}
+}
+
+// nextMode returns the deletion mode after mode, going back to the first one after the last
+func nextMode(mode cleanup.Mode) cleanup.Mode {
+	for index, m := range cleanup.Modes {
+		if m == mode {
+			return cleanup.Modes[(index+1)%len(cleanup.Modes)]
+		}
+	}
+	return cleanup.Modes[0]
+}
+
+// view returns the lines of the screen for a terminal of the given size
+func (m *model) view(width int, height int) []string {
+	var lines []string
+	switch m.state {
+	case selectState:
+		lines = m.viewSelect(height)
+	case confirmState:
+		lines = m.viewConfirm(height)
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return lines
+}
+
+func (m *model) viewSelect(height int) []string {
+	m.height = height - headerLines - footerLines
+	if m.height < 1 {
+		m.height = 1
+	}
+	// Scroll to keep the cursor on the screen
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+m.height {
+		m.top = m.cursor - m.height + 1
+	}
+
+	lines := []string{
+		fmt.Sprintf("gocleasy  %s  %s", m.root.Path(), humanize.Bytes(uint64(m.root.SizeBy(m.metric)))),
+		fmt.Sprintf("    %10s %10s  %s", m.metric.String(), "Files", "Name"),
+	}
+	for index := m.top; index < len(m.rows) && index < m.top+m.height; index++ {
+		line := m.fileRow(m.rows[index])
+		if index == m.cursor {
+			line = reverseVideo + line + resetVideo
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < headerLines+m.height {
+		lines = append(lines, "")
+	}
+
+	status := m.message
+	if status == "" {
+		totals := m.selection.Totals(m.metric)
+		status = fmt.Sprintf("%d selected, %s", m.selection.Len(), humanize.Bytes(uint64(totals.Freed)))
+	}
+	return append(lines, status, "↑↓ move  →← open/close  space select  d delete selected  q quit")
+}
+
+func (m *model) fileRow(file *files.File) string {
+	var mark, arrow, name, numchildren string = "[ ]", "  ", file.Name, "-"
+	if m.selection.IsSelected(file) {
+		mark = "[x]"
+	}
+	if file.IsDir {
+		arrow = "▸ "
+		if m.open[file] {
+			arrow = "▾ "
+		}
+		name += "/"
+		numchildren = humanize.Comma(file.NumChildren)
+	}
+	indent := strings.Repeat("  ", file.Level)
+	return fmt.Sprintf("%s %10s %10s  %s%s%s", mark, humanize.Bytes(uint64(file.SizeBy(m.metric))), numchildren, indent, arrow, name)
+}
+
+func (m *model) viewConfirm(height int) []string {
+	lines := []string{
+		"Delete these files?",
+		fmt.Sprintf("%10s %10s  %s", m.metric.String(), "Files", "Path"),
+	}
+	// Leave room for the totals, the notes and the keys
+	shown := height - len(lines) - 5
+	for index, file := range m.selection.Files {
+		if index >= shown && m.selection.Len() > shown {
+			lines = append(lines, fmt.Sprintf("... and %d more", m.selection.Len()-index))
+			break
+		}
+		path, numchildren := file.Path(), "-"
+		if file.IsDir {
+			path += "/"
+			numchildren = humanize.Comma(file.NumChildren)
+		}
+		lines = append(lines, fmt.Sprintf("%10s %10s  %s", humanize.Bytes(uint64(file.SizeBy(m.metric))), numchildren, path))
+	}
+
+	totals := m.selection.Totals(m.metric)
+	lines = append(lines, fmt.Sprintf("Total freed: %s files, %s", humanize.Comma(totals.NumFiles), humanize.Bytes(uint64(totals.Freed))))
+	if totals.Freed < totals.Size {
+		// Hard linked files only free space when all their links are deleted
+		lines = append(lines, fmt.Sprintf("%s are hard links to files kept outside the selection and will not be freed", humanize.Bytes(uint64(totals.Size-totals.Freed))))
+	}
+	var modes []string
+	for _, mode := range cleanup.Modes {
+		mark := "( )"
+		if mode == m.mode {
+			mark = "(*)"
+		}
+		modes = append(modes, mark+" "+mode.String())
+	}
+	lines = append(lines, strings.Join(modes, "  "))
+	return append(lines, fmt.Sprintf("y %s  p change  n go back  q quit", strings.ToLower(m.mode.String())))
+}
+
+// truncate cuts the line so it fits in width columns
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	// The highlighting must be reset even if the end of the line is cut
+	highlighted := strings.HasPrefix(line, reverseVideo)
+	line = strings.TrimPrefix(strings.TrimSuffix(line, resetVideo), reverseVideo)
+	runes := []rune(line)
+	if len(runes) > width {
+		line = string(runes[:width])
+	}
+	if highlighted {
+		return reverseVideo + line + resetVideo
+	}
+	return line
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"gocleasy/cleanup"
+	"gocleasy/files"
+	"gocleasy/protect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestModel() *model {
+	root := files.NewTestFolder("b",
+		files.NewTestFolder("d",
+			files.NewTestFile("e", 50),
+			files.NewTestFile("f", 30),
+		),
+		files.NewTestFile("c", 70),
+	)
+	root.UpdateSize(-1)
+	return newModel(root, files.ApparentSize, &protect.Guard{})
+}
+
+func press(m *model, keys ...key) {
+	for _, k := range keys {
+		m.update(k)
+	}
+}
+
+func TestModelNavigation(t *testing.T) {
+	m := newTestModel()
+	d, e := files.FindTestFile(m.root, "d"), files.FindTestFile(m.root, "e")
+	assert.Len(t, m.rows, 2)
+	assert.Same(t, d, m.current())
+
+	press(m, keyRight, keyDown)
+	assert.Len(t, m.rows, 4, "opening a folder should show its content")
+	assert.Same(t, e, m.current())
+
+	press(m, keyLeft)
+	assert.Len(t, m.rows, 2, "left should close the folder containing the file")
+	assert.Same(t, d, m.current())
+
+	press(m, keyEnter, keyEnd)
+	assert.Equal(t, "c", m.current().Name)
+	press(m, keyDown, keyHome, keyUp)
+	assert.Same(t, d, m.current(), "the cursor should not leave the list")
+	press(m, keyEnter)
+	assert.Len(t, m.rows, 2, "enter should close an opened folder")
+}
+
+func TestModelSelect(t *testing.T) {
+	m := newTestModel()
+	press(m, 'd')
+	assert.Equal(t, selectState, m.state, "nothing can be deleted without selecting it")
+	assert.NotEmpty(t, m.message)
+
+	press(m, keySpace, keySpace)
+	assert.Equal(t, []*files.File{files.FindTestFile(m.root, "d"), files.FindTestFile(m.root, "c")}, m.selection.Files)
+	press(m, keySpace)
+	assert.Equal(t, 1, m.selection.Len(), "space should unselect a selected file")
+
+	press(m, 'd')
+	assert.Equal(t, confirmState, m.state)
+	screen := strings.Join(m.view(80, 24), "\n")
+	assert.Contains(t, screen, "Delete these files?")
+	assert.Contains(t, screen, "Total freed: 2 files, 80 B")
+	assert.Contains(t, screen, "y move to trash")
+	press(m, 'p')
+	screen = strings.Join(m.view(80, 24), "\n")
+	assert.Contains(t, screen, "(*) Quarantine")
+	assert.Contains(t, screen, "y quarantine")
+	press(m, 'n')
+	assert.Equal(t, selectState, m.state)
+	assert.True(t, m.update('q'))
+}
+
+func TestModelView(t *testing.T) {
+	m := newTestModel()
+	press(m, keyRight)
+	lines := m.view(40, 6)
+	assert.Len(t, lines, 6)
+	assert.Contains(t, lines[2], "d/")
+	assert.True(t, strings.HasPrefix(lines[2], reverseVideo), "the row under the cursor should be highlighted")
+	assert.Contains(t, lines[3], "e")
+	assert.Equal(t, "0 selected, 0 B", lines[4])
+	for _, line := range m.view(20, 6) {
+		assert.LessOrEqual(t, len([]rune(strings.TrimSuffix(strings.TrimPrefix(line, reverseVideo), resetVideo))), 20)
+	}
+
+	press(m, keyDown, keyDown, keyDown)
+	lines = m.view(40, 6)
+	assert.Contains(t, lines[3], "c", "the list should scroll to show the cursor")
+	assert.Equal(t, 2, m.top)
+}
+
+func TestModelDelete(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "d"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), make([]byte, 100), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e"), make([]byte, 50), 0644))
+	root := files.WalkFolder(dir, ioutil.ReadDir, func(string) bool { return false }, nil, files.WalkOptions{}).Root
+	files.SortDescBy(root, files.ApparentSize)
+	m := newModel(root, files.ApparentSize, &protect.Guard{})
+
+	press(m, keySpace, 'd', 'p', 'p', 'y')
+	assert.Equal(t, selectState, m.state)
+	assert.True(t, strings.HasPrefix(m.message, "1 files deleted, "), m.message)
+	assert.Equal(t, cleanup.MoveToTrashMode, m.mode, "deleting permanently should be chosen every time")
+	assert.NoFileExists(t, filepath.Join(dir, "c"))
+	assert.Len(t, m.rows, 1)
+	assert.Equal(t, "d", m.current().Name)
+	assert.Equal(t, int64(50), root.Size)
+}
+
+func TestModelProtected(t *testing.T) {
+	m := newTestModel()
+	m.guard = &protect.Guard{Globs: []string{"e"}}
+	press(m, keySpace, 'd')
+	assert.Equal(t, selectState, m.state, "a selection touching protected files should be refused")
+	assert.Contains(t, m.message, "Cannot delete the selection: ")
+	assert.Contains(t, m.message, "contains the protected ")
+	press(m, keyUp, keySpace, keyDown, keySpace, 'd')
+	assert.Equal(t, confirmState, m.state)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package tui
+
+// makeRaw puts the terminal in raw mode, which is not done on this OS
+func makeRaw(fd int) (func() error, error) {
+	return nil, ErrUnsupported
+}
+
+// terminalSize returns the columns and rows of the terminal, which are not read on this OS
+func terminalSize(fd int) (int, int, error) {
+	return 0, 0, ErrUnsupported
+}
@@ -0,0 +1,78 @@
+// Package tui lets the files be selected and deleted with the keyboard in a
+// terminal, for computers without a screen
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"gocleasy/files"
+	"gocleasy/protect"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrUnsupported is returned when the terminal cannot be controlled on this OS
+var ErrUnsupported = errors.New("the terminal interface is not supported on this OS")
+
+// Size used when the size of the terminal cannot be read
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Run shows the files of root in the terminal until the user quits. The files are
+// browsed and selected like in the window and the selected ones can be deleted
+func Run(root *files.File, metric files.SizeMetric, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	screen := bufio.NewWriter(out)
+	// Use the alternate screen, so the terminal is left as it was, and hide the cursor
+	screen.WriteString("\033[?1049h\033[?25l")
+	defer func() {
+		screen.WriteString("\033[?25h\033[?1049l")
+		screen.Flush()
+	}()
+
+	// Logs would be written over the screen, the files that cannot be deleted are
+	// shown in the status line instead
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	m := newModel(root, metric, protect.Default(root.Path()))
+	input := make([]byte, 64)
+	for {
+		width, height, err := terminalSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = defaultWidth, defaultHeight
+		}
+		draw(screen, m.view(width, height))
+		if err := screen.Flush(); err != nil {
+			return err
+		}
+
+		n, err := in.Read(input)
+		if err != nil {
+			return err
+		}
+		for _, k := range parseKeys(input[:n]) {
+			if m.update(k) {
+				return nil
+			}
+		}
+	}
+}
+
+// draw replaces what the terminal shows by the lines
+func draw(w io.StringWriter, lines []string) {
+	w.WriteString("\033[H")
+	w.WriteString(strings.Join(lines, "\033[K\r\n"))
+	w.WriteString("\033[K\033[J")
+}